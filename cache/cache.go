@@ -3,6 +3,8 @@ package cache
 import (
 	"archive/zip"
 	"bufio"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -24,6 +26,22 @@ const (
 	historyPath    = "/history"
 	pageSuffix     = ".md"
 	zipPath        = "/tldr.zip"
+	remotePath     = "/remote.json"
+
+	// stagingPrefix starts the names of the directories the cache is
+	// reloaded to, the previous cache is moved aside with oldSuffix.
+	stagingPrefix = "tldr-staging-"
+	oldSuffix     = ".old"
+	// stagingTimeout is the time after which the leftovers of a reload are
+	// taken as interrupted.
+	stagingTimeout = time.Hour
+
+	// maxArchiveSize limits the size of the downloaded archive.
+	maxArchiveSize int64 = 256 << 20
+	// maxExtractedFiles and maxExtractedSize limit the number of files and
+	// their total size extracted from the archive.
+	maxExtractedFiles       = 200000
+	maxExtractedSize  int64 = 2 << 30
 )
 
 // Repository keeps a copy of the data from the remote location on the local
@@ -33,6 +51,27 @@ type Repository struct {
 	directory string
 	remote    string
 	ttl       time.Duration
+	checksum  string
+	// checksumRequired fails loading if there is no checksum, instead of
+	// skipping the verification.
+	checksumRequired bool
+}
+
+// Options configures a Repository.
+type Options struct {
+	// ChecksumURL is the location of the SHA-256 checksum the archive loaded
+	// from the remote is verified against. It defaults to the file
+	// "tldr.sha256sums" next to the remote, the verification is skipped
+	// with a warning if that doesn't exist, as a mirror might not publish
+	// it.
+	ChecksumURL string
+}
+
+// remoteVersion identifies the version of the remote data in the cache by
+// the validators of the response it was loaded from.
+type remoteVersion struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
 }
 
 // HistoryRecord represent the search history of certain page
@@ -48,36 +87,60 @@ func (h HistoryRecord) String() string {
 // NewRepository returns a new cache repository. The data is loaded from the
 // remote if missing or stale.
 func NewRepository(remote string, ttl time.Duration) (*Repository, error) {
+	return NewRepositoryWithOptions(remote, ttl, Options{})
+}
+
+// NewRepositoryWithOptions returns a new cache repository configured by the
+// options. The data is loaded from the remote if missing or stale.
+func NewRepositoryWithOptions(remote string, ttl time.Duration, opts Options) (*Repository, error) {
+	repo, err := newRepository(remote, ttl, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = repo.refresh(pagesDirectory, repo.Reload)
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func newRepository(remote string, ttl time.Duration, opts Options) (*Repository, error) {
 	dir, err := cacheDir()
 	if err != nil {
 		return nil, fmt.Errorf("ERROR: getting cache directory: %s", err)
 	}
 
-	repo := &Repository{directory: dir, remote: remote, ttl: ttl}
+	repo := &Repository{directory: dir, remote: remote, ttl: ttl, checksum: opts.ChecksumURL, checksumRequired: opts.ChecksumURL != ""}
+	if repo.checksum == "" {
+		repo.checksum = defaultChecksumURL(remote)
+	}
+	return repo, nil
+}
 
-	info, err := os.Stat(dir)
-	if os.IsNotExist(err) {
-		err = repo.makeCacheDir()
-		if err != nil {
-			return nil, fmt.Errorf("ERROR: creating cache directory: %s", err)
-		}
-		fmt.Println("fetch pages ...")
-		err = repo.loadFromRemote()
+// refresh loads the data from the remote if the installed file or directory
+// of the cache is missing, and reloads it if the cache is stale. Only failing
+// to load a missing cache is an error.
+func (r *Repository) refresh(installed string, reload func() error) error {
+	info, err := os.Stat(r.directory)
+	if _, installedErr := os.Stat(path.Join(r.directory, installed)); os.IsNotExist(installedErr) {
+		fmt.Fprintln(os.Stderr, "fetch pages ...")
+		err = reload()
 		if err != nil {
-			return nil, fmt.Errorf("ERROR: loading data from remote: %s", err)
+			return fmt.Errorf("ERROR: loading data from remote: %s", err)
 		}
-	} else if err != nil || info.ModTime().Before(time.Now().Add(-ttl)) {
-		if repo.isReachable() {
-			err = repo.Reload()
+	} else if err != nil || info.ModTime().Before(time.Now().Add(-r.ttl)) {
+		if r.isReachable() {
+			// The previous cache is kept if reloading fails, so it is used
+			// until the remote can be loaded again.
+			err = reload()
 			if err != nil {
-				return nil, fmt.Errorf("ERROR: reloading cache: %s", err)
+				fmt.Fprintf(os.Stderr, "WARNING: reloading cache failed, using the previous one: %s\n", err)
 			}
 		} else {
-			fmt.Println("INFO: remote is not reachable, reload skipped")
+			fmt.Fprintln(os.Stderr, "INFO: remote is not reachable, reload skipped")
 		}
 	}
-
-	return repo, nil
+	return nil
 }
 
 // AvailablePlatforms returns all the availale platforms found in cache.
@@ -127,91 +190,326 @@ func (r *Repository) Pages() ([]string, error) {
 	return names, nil
 }
 
-// Reload removes the cache directory, recreates it, and saves the data from the remote
-// to the local filesystem.
+// Reload saves the data from the remote to a staging directory next to the
+// cache directory and swaps it in place of the cache once it is complete. The
+// previous cache is kept if anything fails, the history is taken over. If the
+// remote didn't change since the cache was loaded, only the modification
+// time of the cache is updated.
 func (r *Repository) Reload() error {
-	err := os.RemoveAll(r.directory)
+	return r.reload(extractPages, validateCache)
+}
+
+// extractPages extracts the downloaded archive in the directory.
+func extractPages(dir string) error {
+	err := unzip(dir)
 	if err != nil {
-		return fmt.Errorf("ERROR: removing cache directory: %s", err)
+		return fmt.Errorf("ERROR: unzipping pages: %s", err)
 	}
 
-	err = r.makeCacheDir()
+	err = os.Remove(dir + zipPath)
 	if err != nil {
-		return fmt.Errorf("ERROR: creating cache directory: %s", err)
+		return fmt.Errorf("ERROR: removing zip: %s", err)
 	}
+	return validateCache(dir)
+}
 
-	err = r.loadFromRemote()
+// reload loads the archive from the remote to a staging directory, where
+// install prepares the pages, and swaps it in place of the cache. validate
+// checks whether the cache holds pages the remote version belongs to.
+func (r *Repository) reload(install, validate func(dir string) error) error {
+	parent := filepath.Dir(r.directory)
+	err := os.MkdirAll(parent, 0755)
+	if err != nil {
+		return fmt.Errorf("ERROR: creating directory %s: %s", parent, err)
+	}
+	cleanStaging(r.directory)
+	staging, err := os.MkdirTemp(parent, stagingPrefix)
+	if err != nil {
+		return fmt.Errorf("ERROR: creating staging directory: %s", err)
+	}
+	// Nothing is left to remove once the staging directory is swapped in.
+	defer os.RemoveAll(staging)
+
+	// The version is only sent if the pages it belongs to are still there.
+	var version remoteVersion
+	if validate(r.directory) == nil {
+		version = loadRemoteVersion(r.directory)
+	}
+	modified, err := r.loadFromRemote(staging, version)
 	if err != nil {
 		return fmt.Errorf("ERROR: loading data from remote: %s", err)
 	}
+	if !modified {
+		now := time.Now()
+		err = os.Chtimes(r.directory, now, now)
+		if err != nil {
+			return fmt.Errorf("ERROR: touching cache directory: %s", err)
+		}
+		return nil
+	}
+	err = install(staging)
+	if err != nil {
+		return err
+	}
+	err = copyHistory(r.directory, staging)
+	if err != nil {
+		return err
+	}
+	return swapDirectory(staging, r.directory)
+}
+
+// validateCache checks that the directory holds pages.
+func validateCache(dir string) error {
+	found := false
+	err := filepath.Walk(path.Join(dir, pagesDirectory), func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !f.IsDir() && strings.HasSuffix(f.Name(), pageSuffix) {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("ERROR: validating downloaded pages: %s", err)
+	}
+	if !found {
+		return fmt.Errorf("ERROR: validating downloaded pages: no pages found")
+	}
 	return nil
 }
 
-func (r *Repository) copyZipFile(f *zip.File) error {
-	zipFile, err := f.Open()
+// copyHistory copies the history file of the cache directory from to the
+// cache directory to, an empty history is created if there is none.
+func copyHistory(from, to string) error {
+	history, err := os.ReadFile(path.Join(from, historyPath))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("ERROR: reading history file: %s", err)
+	}
+	err = os.WriteFile(path.Join(to, historyPath), history, 0644)
 	if err != nil {
-		return fmt.Errorf("ERROR: opening file '%s': %s", f.Name, err)
+		return fmt.Errorf("ERROR: writing history file: %s", err)
 	}
-	defer zipFile.Close()
+	return nil
+}
 
-	filepath := path.Join(r.directory, f.Name)
-	if f.FileInfo().IsDir() {
-		err := os.MkdirAll(filepath, os.ModePerm)
+// cleanStaging removes what interrupted reloads left next to the cache
+// directory. A previous cache that was moved aside but not replaced is moved
+// back, so its history isn't lost. Recent leftovers may belong to a reload
+// that is still running and are kept.
+func cleanStaging(dir string) {
+	leftovers, err := filepath.Glob(filepath.Join(filepath.Dir(dir), stagingPrefix+"*"))
+	if err != nil {
+		return
+	}
+	for _, leftover := range leftovers {
+		if strings.HasSuffix(leftover, oldSuffix) {
+			if _, err := os.Stat(dir); os.IsNotExist(err) && os.Rename(leftover, dir) == nil {
+				continue
+			}
+		}
+		info, err := os.Stat(leftover)
+		if err == nil && time.Since(info.ModTime()) > stagingTimeout {
+			_ = os.RemoveAll(leftover)
+		}
+	}
+}
+
+// swapDirectory replaces the directory dest by the directory src. The
+// previous directory is moved aside first and restored if src can't be moved
+// in its place.
+func swapDirectory(src, dest string) error {
+	old := src + oldSuffix
+	err := os.Rename(dest, old)
+	if os.IsNotExist(err) {
+		old = ""
+	} else if err != nil {
+		return fmt.Errorf("ERROR: moving previous cache aside: %s", err)
+	}
+
+	err = os.Rename(src, dest)
+	if err != nil {
+		if old != "" {
+			_ = os.Rename(old, dest)
+		}
+		return fmt.Errorf("ERROR: moving new cache in place: %s", err)
+	}
+
+	if old != "" {
+		err = os.RemoveAll(old)
+		if err != nil {
+			return fmt.Errorf("ERROR: removing previous cache: %s", err)
+		}
+	}
+	return nil
+}
+
+// extractor writes the files of an archive to a directory, within limits
+// protecting against archives filling up the disk.
+type extractor struct {
+	dir      string
+	maxFiles int
+	maxSize  int64
+	files    int
+	size     int64
+}
+
+func newExtractor(dir string) *extractor {
+	return &extractor{dir: dir, maxFiles: maxExtractedFiles, maxSize: maxExtractedSize}
+}
+
+// extract writes the file of the archive below the directory. Directories
+// and files are created with fixed permissions, symlinks and other special
+// files are skipped.
+func (e *extractor) extract(f *zip.File) error {
+	name, err := entryPath(f.Name)
+	if err != nil {
+		return err
+	}
+	filepath := path.Join(e.dir, name)
+	mode := f.Mode()
+	if mode.IsDir() {
+		err := os.MkdirAll(filepath, 0755)
 		if err != nil {
 			return fmt.Errorf("ERROR: making directory '%s': %s", filepath, err)
 		}
 		return nil
 	}
+	if !mode.IsRegular() {
+		return nil
+	}
 
-	var dirPath string
-	if lastIndex := strings.LastIndex(filepath, string(os.PathSeparator)); lastIndex > -1 {
-		dirPath = filepath[:lastIndex]
+	e.files++
+	if e.files > e.maxFiles {
+		return fmt.Errorf("ERROR: archive has more than %d files", e.maxFiles)
+	}
+	// The size in the header is checked first, but can't be trusted.
+	if int64(f.UncompressedSize64) > e.maxSize-e.size {
+		return fmt.Errorf("ERROR: archive exceeds %d bytes uncompressed", e.maxSize)
 	}
 
-	err = os.MkdirAll(dirPath, os.ModePerm)
+	err = os.MkdirAll(path.Dir(filepath), 0755)
 	if err != nil {
 		return fmt.Errorf("ERROR: making directories for '%s': %s", filepath, err)
 	}
 
-	file, err := os.OpenFile(filepath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+	zipFile, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("ERROR: opening file '%s': %s", f.Name, err)
+	}
+	defer zipFile.Close()
+
+	file, err := os.OpenFile(filepath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
 		return fmt.Errorf("ERROR: opening file '%s': %s", filepath, err)
 	}
 	defer file.Close()
 
-	_, err = io.Copy(file, zipFile)
+	written, err := io.Copy(file, io.LimitReader(zipFile, e.maxSize-e.size+1))
+	e.size += written
 	if err != nil {
 		return fmt.Errorf("ERROR: copying file '%s': %s", file.Name(), err)
 	}
+	if e.size > e.maxSize {
+		return fmt.Errorf("ERROR: archive exceeds %d bytes uncompressed", e.maxSize)
+	}
 	return nil
 }
 
-func (r *Repository) loadFromRemote() error {
-	cache, err := os.Create(r.directory + zipPath)
+// entryPath returns the relative path of an archive entry. Absolute paths
+// and paths leaving the directory the archive is extracted to are rejected.
+func entryPath(name string) (string, error) {
+	slashed := strings.ReplaceAll(name, "\\", "/")
+	if slashed == "" || path.IsAbs(slashed) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("ERROR: invalid path '%s' in archive", name)
+	}
+	for _, element := range strings.Split(slashed, "/") {
+		if element == ".." {
+			return "", fmt.Errorf("ERROR: invalid path '%s' in archive", name)
+		}
+	}
+	return path.Clean(slashed), nil
+}
+
+// loadFromRemote saves the archive from the remote to dir, unless it is
+// still the given version. It reports whether the remote was modified.
+func (r *Repository) loadFromRemote(dir string, version remoteVersion) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, r.remote, nil)
 	if err != nil {
-		return fmt.Errorf("ERROR: creating cache: %s", err)
+		return false, fmt.Errorf("ERROR: creating request: %s", err)
+	}
+	if version.ETag != "" {
+		req.Header.Set("If-None-Match", version.ETag)
+	}
+	if version.LastModified != "" {
+		req.Header.Set("If-Modified-Since", version.LastModified)
 	}
-	defer cache.Close()
 
-	resp, err := http.Get(r.remote)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("ERROR: getting response from remote: %s", err)
+		return false, fmt.Errorf("ERROR: getting response from remote: %s", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+	err = checkArchiveResponse(resp)
+	if err != nil {
+		return false, err
+	}
 
-	_, err = io.Copy(cache, resp.Body)
+	cache, err := os.Create(dir + zipPath)
 	if err != nil {
-		return fmt.Errorf("ERROR: copying response body to cache: %s", err)
+		return false, fmt.Errorf("ERROR: creating cache: %s", err)
 	}
+	defer cache.Close()
 
-	err = r.unzip()
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(cache, hash), io.LimitReader(resp.Body, maxArchiveSize+1))
 	if err != nil {
-		return fmt.Errorf("ERROR: unzipping pages: %s", err)
+		return false, fmt.Errorf("ERROR: copying response body to cache: %s", err)
+	}
+	if size == 0 {
+		return false, fmt.Errorf("ERROR: remote returned an empty archive")
+	} else if size > maxArchiveSize {
+		return false, fmt.Errorf("ERROR: archive exceeds %d bytes", maxArchiveSize)
+	}
+	err = r.verifyChecksum(hash.Sum(nil))
+	if err != nil {
+		return false, err
 	}
 
-	err = os.Remove(r.directory + zipPath)
+	version = remoteVersion{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	err = saveRemoteVersion(dir, version)
 	if err != nil {
-		return fmt.Errorf("ERROR: removing zip: %s", err)
+		return false, err
+	}
+	return true, nil
+}
+
+// loadRemoteVersion returns the version of the remote data in the cache
+// directory. It is empty if it is unknown.
+func loadRemoteVersion(dir string) remoteVersion {
+	var version remoteVersion
+	data, err := os.ReadFile(dir + remotePath)
+	if err == nil && json.Unmarshal(data, &version) != nil {
+		return remoteVersion{}
+	}
+	return version
+}
+
+func saveRemoteVersion(dir string, version remoteVersion) error {
+	if version == (remoteVersion{}) {
+		return nil
+	}
+	data, err := json.Marshal(version)
+	if err != nil {
+		return fmt.Errorf("ERROR: encoding remote version: %s", err)
+	}
+	err = os.WriteFile(dir+remotePath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("ERROR: writing remote version: %s", err)
 	}
 	return nil
 }
@@ -234,15 +532,19 @@ func touchFile(fileName string) error {
 	return nil
 }
 
-func (r *Repository) unzip() error {
-	reader, err := zip.OpenReader(r.directory + zipPath)
+func unzip(dir string) error {
+	return newExtractor(dir).unzip(dir + zipPath)
+}
+
+func (e *extractor) unzip(archive string) error {
+	reader, err := zip.OpenReader(archive)
 	if err != nil {
 		return fmt.Errorf("ERROR: opening zip: %s", err)
 	}
 	defer reader.Close()
 
 	for _, f := range reader.File {
-		err = r.copyZipFile(f)
+		err = e.extract(f)
 		if err != nil {
 			return fmt.Errorf("ERROR: copying zip file: %s", err)
 		}
@@ -284,14 +586,20 @@ func (r Repository) isReachable() bool {
 	timeout := time.Duration(seconds) * time.Second
 	var port string
 
-	if u.Scheme == "https" {
+	if u.Port() != "" {
+		port = u.Port()
+	} else if u.Scheme == "https" {
 		port = "443"
 	} else if u.Scheme == "http" {
 		port = "80"
 	}
 
-	_, err = net.DialTimeout("tcp", u.Hostname()+":"+port, timeout)
-	return err == nil
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(u.Hostname(), port), timeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
 }
 
 func (r Repository) RecordHistory(page string) error {
@@ -1,7 +1,15 @@
 package cache
 
 import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -9,6 +17,70 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// zipArchive returns a zip archive holding the files.
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for name, content := range files {
+		file, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = file.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return archive.Bytes()
+}
+
+// serverRepository returns a repository in a temporary directory loading
+// from a server calling the handler for the archive. The server has no
+// checksum of it.
+func serverRepository(t *testing.T, handler http.HandlerFunc) *Repository {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path.Base(r.URL.Path) == checksumsFile {
+			http.NotFound(w, r)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	remote := server.URL + "/tldr.zip"
+	return &Repository{directory: path.Join(t.TempDir(), "tldr"), remote: remote, ttl: time.Hour, checksum: defaultChecksumURL(remote)}
+}
+
+// testRepository returns a repository holding the given page and history,
+// and loading from a server answering with body.
+func testRepository(t *testing.T, page, history string, body []byte) *Repository {
+	r := serverRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	})
+
+	require.NoError(t, os.MkdirAll(path.Join(r.directory, pagesDirectory, "common"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(r.directory, pagesDirectory, "common", "cat.md"), []byte(page), 0644))
+	require.NoError(t, os.WriteFile(path.Join(r.directory, historyPath), []byte(history), 0644))
+	return r
+}
+
+func readPage(t *testing.T, r *Repository, platform, page string) string {
+	markdown, err := r.Markdown(platform, page)
+	require.NoError(t, err, "Markdown() error %v", err)
+	defer markdown.Close()
+
+	var content bytes.Buffer
+	_, err = content.ReadFrom(markdown)
+	require.NoError(t, err)
+	return content.String()
+}
+
+// requireNoStaging fails if staging directories are left next to the cache.
+func requireNoStaging(t *testing.T, r *Repository) {
+	entries, err := os.ReadDir(filepath.Dir(r.directory))
+	require.NoError(t, err)
+	for _, entry := range entries {
+		require.Equal(t, "tldr", entry.Name(), "unexpected leftover %s", entry.Name())
+	}
+}
+
 func TestCacheDir(t *testing.T) {
 	cacheDirectory, err := cacheDir()
 
@@ -175,3 +247,365 @@ func TestHistory(t *testing.T) {
 	}
 
 }
+
+func TestReloadSwapsCache(t *testing.T) {
+	body := zipArchive(t, map[string]string{
+		"pages/common/cat.md": "# cat\n",
+		"pages/linux/tar.md":  "# tar\n",
+	})
+	r := testRepository(t, "# old cat\n", "cat,3\n", body)
+
+	require.NoError(t, r.Reload())
+	require.Equal(t, "# cat\n", readPage(t, r, "common", "cat"))
+	require.Equal(t, "# tar\n", readPage(t, r, "linux", "tar"))
+
+	history, err := r.LoadHistory()
+	require.NoError(t, err, "LoadHistory() error %v", err)
+	require.Equal(t, []HistoryRecord{{page: "cat", count: 3}}, *history)
+	requireNoStaging(t, r)
+}
+
+func TestReloadKeepsCacheOnFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{name: "invalid zip", body: []byte("<html>Service Unavailable</html>")},
+		{name: "empty zip", body: zipArchive(t, map[string]string{})},
+		{name: "no pages", body: zipArchive(t, map[string]string{"LICENSE.md": "license"})},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := testRepository(t, "# old cat\n", "cat,3\n", tt.body)
+
+			require.Error(t, r.Reload())
+			require.Equal(t, "# old cat\n", readPage(t, r, "common", "cat"))
+			history, err := r.LoadHistory()
+			require.NoError(t, err, "LoadHistory() error %v", err)
+			require.Equal(t, []HistoryRecord{{page: "cat", count: 3}}, *history)
+			requireNoStaging(t, r)
+		})
+	}
+}
+
+func TestReloadWithoutCache(t *testing.T) {
+	r := testRepository(t, "", "", zipArchive(t, map[string]string{"pages/common/cat.md": "# cat\n"}))
+	require.NoError(t, os.RemoveAll(r.directory))
+
+	require.NoError(t, r.Reload())
+	require.Equal(t, "# cat\n", readPage(t, r, "common", "cat"))
+	history, err := r.LoadHistory()
+	require.NoError(t, err, "LoadHistory() error %v", err)
+	require.Empty(t, *history)
+}
+
+func TestRefreshKeepsStaleCache(t *testing.T) {
+	r := testRepository(t, "# old cat\n", "cat,3\n", []byte("<html>Captive portal</html>"))
+	stale := time.Now().Add(-2 * r.ttl)
+	require.NoError(t, os.Chtimes(r.directory, stale, stale))
+
+	require.NoError(t, r.refresh(pagesDirectory, r.Reload))
+	require.Equal(t, "# old cat\n", readPage(t, r, "common", "cat"))
+	requireNoStaging(t, r)
+
+	// Without a cache there is nothing to fall back to.
+	require.NoError(t, os.RemoveAll(r.directory))
+	require.Error(t, r.refresh(pagesDirectory, r.Reload))
+}
+
+func TestReloadCleansStaging(t *testing.T) {
+	r := testRepository(t, "# old cat\n", "cat,3\n", zipArchive(t, map[string]string{"pages/common/cat.md": "# cat\n"}))
+	parent := filepath.Dir(r.directory)
+
+	// A reload interrupted between moving the cache aside and moving the
+	// new one in, and another one interrupted while loading.
+	old := path.Join(parent, stagingPrefix+"1"+oldSuffix)
+	require.NoError(t, os.Rename(r.directory, old))
+	require.NoError(t, os.MkdirAll(path.Join(parent, stagingPrefix+"1", pagesDirectory), 0755))
+	require.NoError(t, os.MkdirAll(path.Join(parent, stagingPrefix+"2"), 0755))
+	interrupted := time.Now().Add(-2 * stagingTimeout)
+	for _, leftover := range []string{old, path.Join(parent, stagingPrefix+"1"), path.Join(parent, stagingPrefix+"2")} {
+		require.NoError(t, os.Chtimes(leftover, interrupted, interrupted))
+	}
+	// A reload that is still running.
+	running := path.Join(parent, stagingPrefix+"3")
+	require.NoError(t, os.MkdirAll(running, 0755))
+
+	require.NoError(t, r.Reload())
+	require.Equal(t, "# cat\n", readPage(t, r, "common", "cat"))
+	history, err := r.LoadHistory()
+	require.NoError(t, err, "LoadHistory() error %v", err)
+	require.Equal(t, []HistoryRecord{{page: "cat", count: 3}}, *history)
+
+	require.NoError(t, os.Remove(running))
+	requireNoStaging(t, r)
+}
+
+func TestReloadConditional(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator string
+		condition string
+		value     string
+	}{
+		{name: "etag", validator: "ETag", condition: "If-None-Match", value: `"v1"`},
+		{name: "last modified", validator: "Last-Modified", condition: "If-Modified-Since", value: "Wed, 01 Jan 2025 00:00:00 GMT"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			body := zipArchive(t, map[string]string{"pages/common/cat.md": "# cat\n"})
+			downloads := 0
+			r := serverRepository(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(tt.condition) == tt.value {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				downloads++
+				w.Header().Set(tt.validator, tt.value)
+				_, _ = w.Write(body)
+			})
+
+			require.NoError(t, r.Reload())
+			require.Equal(t, 1, downloads)
+
+			// A remote that didn't change only touches the cache.
+			stale := time.Now().Add(-48 * time.Hour)
+			require.NoError(t, os.Chtimes(r.directory, stale, stale))
+			require.NoError(t, r.Reload())
+			require.Equal(t, 1, downloads)
+			require.Equal(t, "# cat\n", readPage(t, r, "common", "cat"))
+			info, err := os.Stat(r.directory)
+			require.NoError(t, err)
+			require.True(t, info.ModTime().After(stale.Add(time.Hour)), "expected the cache to be touched")
+			requireNoStaging(t, r)
+
+			// Without pages the version isn't sent.
+			require.NoError(t, os.RemoveAll(path.Join(r.directory, pagesDirectory)))
+			require.NoError(t, r.Reload())
+			require.Equal(t, 2, downloads)
+			require.Equal(t, "# cat\n", readPage(t, r, "common", "cat"))
+		})
+	}
+}
+
+func TestReloadChecksResponse(t *testing.T) {
+	body := zipArchive(t, map[string]string{"pages/common/cat.md": "# cat\n"})
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		err     string
+	}{
+		{
+			name: "status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			err: "unexpected response status '503 Service Unavailable'",
+		},
+		{
+			name: "captive portal",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				_, _ = w.Write([]byte("<html>Please log in</html>"))
+			},
+			err: "unexpected content type 'text/html', expected a zip archive",
+		},
+		{
+			name: "too large",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "1000000000")
+				w.WriteHeader(http.StatusOK)
+			},
+			err: "archive of 1000000000 bytes exceeds",
+		},
+		{
+			name:    "empty",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			err:     "remote returned an empty archive",
+		},
+		{
+			name: "zip",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/zip")
+				_, _ = w.Write(body)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := serverRepository(t, tt.handler)
+			err := r.Reload()
+			if tt.err == "" {
+				require.NoError(t, err, "Reload() error %v", err)
+				return
+			}
+			require.ErrorContains(t, err, tt.err)
+			requireNoStaging(t, r)
+		})
+	}
+}
+
+func TestReloadVerifiesChecksum(t *testing.T) {
+	body := zipArchive(t, map[string]string{"pages/common/cat.md": "# cat\n"})
+	sum := sha256.Sum256(body)
+	checksum := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name      string
+		checksums string
+		status    int
+		required  bool
+		err       string
+	}{
+		{name: "single checksum", checksums: checksum + "\n"},
+		{name: "sha256sum", checksums: strings.Repeat("0", 64) + "  tldr-en.zip\n" + strings.ToUpper(checksum) + " *tldr.zip\n"},
+		{name: "mismatch", checksums: strings.Repeat("0", 64) + "\n", err: "checksum mismatch"},
+		{name: "invalid", checksums: "<html></html>\n", err: "invalid checksum '<html></html>'"},
+		{name: "other files only", checksums: checksum + "  tldr-en.zip\n", err: "no checksum for 'tldr.zip' found"},
+		{name: "missing", status: http.StatusNotFound},
+		{name: "missing required", status: http.StatusNotFound, required: true, err: "unexpected response status '404 Not Found'"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/tldr.zip":
+					_, _ = w.Write(body)
+				case tt.status != 0:
+					w.WriteHeader(tt.status)
+				default:
+					_, _ = w.Write([]byte(tt.checksums))
+				}
+			}))
+			defer server.Close()
+			r := &Repository{
+				directory:        path.Join(t.TempDir(), "tldr"),
+				remote:           server.URL + "/tldr.zip",
+				checksum:         server.URL + "/tldr.sha256sums",
+				checksumRequired: tt.required,
+			}
+
+			err := r.Reload()
+			if tt.err == "" {
+				require.NoError(t, err, "Reload() error %v", err)
+				require.Equal(t, "# cat\n", readPage(t, r, "common", "cat"))
+				return
+			}
+			require.ErrorContains(t, err, tt.err)
+			_, err = os.Stat(r.directory)
+			require.True(t, os.IsNotExist(err), "expected no cache to be installed")
+		})
+	}
+}
+
+func TestDefaultChecksumURL(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{remote: "https://tldr.sh/assets/tldr.zip", want: "https://tldr.sh/assets/tldr.sha256sums"},
+		{remote: "https://mirror.example.com/tldr/v2/tldr-pages.zip", want: "https://mirror.example.com/tldr/v2/tldr.sha256sums"},
+		{remote: "http://localhost:8080//tldr.zip", want: "http://localhost:8080//tldr.sha256sums"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.remote, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, defaultChecksumURL(tt.remote))
+		})
+	}
+}
+
+// zipEntry is a file of a test archive with its mode.
+type zipEntry struct {
+	name    string
+	content string
+	mode    os.FileMode
+}
+
+func writeZipEntries(t *testing.T, file string, entries []zipEntry) {
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		header.SetMode(entry.mode)
+		w, err := writer.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, os.WriteFile(file, archive.Bytes(), 0644))
+}
+
+func TestExtractRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		err     string
+	}{
+		{name: "traversal", entries: []zipEntry{{name: "pages/../../evil.md", mode: 0644}}, err: "invalid path 'pages/../../evil.md'"},
+		{name: "backslash traversal", entries: []zipEntry{{name: `..\evil.md`, mode: 0644}}, err: `invalid path '..\evil.md'`},
+		{name: "absolute", entries: []zipEntry{{name: "/etc/evil", mode: 0644}}, err: "invalid path '/etc/evil'"},
+		{name: "too many files", entries: []zipEntry{{name: "a.md", mode: 0644}, {name: "b.md", mode: 0644}, {name: "c.md", mode: 0644}}, err: "archive has more than 2 files"},
+		{name: "too large", entries: []zipEntry{{name: "a.md", content: "123456", mode: 0644}, {name: "b.md", content: "123456", mode: 0644}}, err: "archive exceeds 10 bytes uncompressed"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			root := t.TempDir()
+			dir := path.Join(root, "cache")
+			archive := path.Join(root, "tldr.zip")
+			writeZipEntries(t, archive, tt.entries)
+
+			e := &extractor{dir: dir, maxFiles: 2, maxSize: 10}
+			require.ErrorContains(t, e.unzip(archive), tt.err)
+			_, err := os.Stat(path.Join(root, "evil.md"))
+			require.True(t, os.IsNotExist(err), "expected nothing written outside of the cache")
+		})
+	}
+}
+
+func TestExtractNormalizes(t *testing.T) {
+	root := t.TempDir()
+	archive := path.Join(root, "tldr.zip")
+	writeZipEntries(t, archive, []zipEntry{
+		{name: "pages/", mode: os.ModeDir | 0700},
+		{name: "pages/common/cat.md", content: "# cat\n", mode: 0777},
+		{name: "pages/common/link.md", content: "/etc/passwd", mode: os.ModeSymlink | 0777},
+		{name: "./pages/linux/tar.md", content: "# tar\n", mode: 0600},
+	})
+
+	e := newExtractor(path.Join(root, "cache"))
+	require.NoError(t, e.unzip(archive))
+
+	// The permissions of the archive are replaced, the umask may only
+	// remove some.
+	for file, mode := range map[string]os.FileMode{
+		"pages":               os.ModeDir | 0755,
+		"pages/common/cat.md": 0644,
+		"pages/linux/tar.md":  0644,
+	} {
+		info, err := os.Stat(path.Join(e.dir, file))
+		require.NoError(t, err)
+		require.Zero(t, info.Mode()&^mode, "unexpected mode %s of %s", info.Mode(), file)
+		require.Equal(t, mode&os.ModeDir, info.Mode()&os.ModeDir, file)
+	}
+	_, err := os.Lstat(path.Join(e.dir, "pages/common/link.md"))
+	require.True(t, os.IsNotExist(err), "expected symlinks to be skipped")
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	// checksumsFile is the list of checksums published next to the archive
+	// by tldr-pages, in the format of sha256sum.
+	checksumsFile = "tldr.sha256sums"
+	// sha256Length is the length of a hex encoded SHA-256 sum.
+	sha256Length = 64
+
+	// maxChecksumSize limits the size of the downloaded checksum file.
+	maxChecksumSize = 64 << 10
+)
+
+// Content types the archive may be served with, others like text/html are
+// likely an error page of a proxy or captive portal.
+var archiveContentTypes = map[string]bool{
+	"":                             true,
+	"application/zip":              true,
+	"application/x-zip-compressed": true,
+	"application/octet-stream":     true,
+	"binary/octet-stream":          true,
+}
+
+// checkArchiveResponse checks the status, content type and size of the
+// response to the archive request before it is saved.
+func checkArchiveResponse(resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ERROR: unexpected response status '%s'", resp.Status)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if contentType != "" && err != nil {
+		return fmt.Errorf("ERROR: invalid content type '%s': %s", contentType, err)
+	}
+	if !archiveContentTypes[mediaType] {
+		return fmt.Errorf("ERROR: unexpected content type '%s', expected a zip archive", mediaType)
+	}
+
+	if resp.ContentLength > maxArchiveSize {
+		return fmt.Errorf("ERROR: archive of %d bytes exceeds %d bytes", resp.ContentLength, maxArchiveSize)
+	}
+	return nil
+}
+
+// defaultChecksumURL returns the location of the checksums next to the
+// archive at remote.
+func defaultChecksumURL(remote string) string {
+	return remote[:strings.LastIndex(remote, "/")+1] + checksumsFile
+}
+
+// verifyChecksum compares the SHA-256 sum of the archive with the checksum
+// published for it. A missing checksum is only accepted, with a warning, if
+// the checksum URL was derived from the remote.
+func (r *Repository) verifyChecksum(sum []byte) error {
+	resp, err := http.Get(r.checksum)
+	if err != nil {
+		return fmt.Errorf("ERROR: getting checksum: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound && !r.checksumRequired {
+		fmt.Fprintf(os.Stderr, "WARNING: no checksum found at %s, the pages archive is not verified\n", r.checksum)
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ERROR: getting checksum: unexpected response status '%s'", resp.Status)
+	}
+
+	want, err := parseChecksum(io.LimitReader(resp.Body, maxChecksumSize), path.Base(r.remote))
+	if err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(sum); got != want {
+		return fmt.Errorf("ERROR: checksum mismatch: archive has %s, expected %s", got, want)
+	}
+	return nil
+}
+
+// parseChecksum reads the checksum of the file from a checksum file, which
+// is either a single checksum or has a line per file in the format of
+// sha256sum.
+func parseChecksum(checksums io.Reader, file string) (string, error) {
+	scanner := bufio.NewScanner(checksums)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		// sha256sum marks files read in binary mode with an asterisk.
+		if len(fields) == 1 || strings.TrimPrefix(fields[1], "*") == file {
+			checksum := strings.ToLower(fields[0])
+			if _, err := hex.DecodeString(checksum); err != nil || len(checksum) != sha256Length {
+				return "", fmt.Errorf("ERROR: invalid checksum '%s'", fields[0])
+			}
+			return checksum, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("ERROR: reading checksum: %s", err)
+	}
+	return "", fmt.Errorf("ERROR: no checksum for '%s' found", file)
+}
@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ZipRepository is a cache that keeps the pages archive as it is downloaded
+// and serves the pages from it, instead of extracting it.
+type ZipRepository struct {
+	*Repository
+	reader *zip.ReadCloser
+	index  archiveIndex
+}
+
+// archiveIndex holds the pages of an archive by platform and name.
+type archiveIndex map[string]map[string]*zip.File
+
+// NewZipRepository returns a new zip cache repository. The data is loaded
+// from the remote if there is no archive in the cache or the cache is stale.
+func NewZipRepository(remote string, ttl time.Duration, opts Options) (*ZipRepository, error) {
+	repo, err := newRepository(remote, ttl, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	zipRepo := &ZipRepository{Repository: repo}
+	err = repo.refresh(zipPath, func() error { return repo.reload(validateArchive, validateArchive) })
+	if err != nil {
+		return nil, err
+	}
+	err = zipRepo.open()
+	if err != nil {
+		return nil, err
+	}
+	return zipRepo, nil
+}
+
+// AvailablePlatforms returns all the available platforms.
+func (r *ZipRepository) AvailablePlatforms() ([]string, error) {
+	platforms := make([]string, 0, len(r.index))
+	for platform := range r.index {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	return platforms, nil
+}
+
+// Markdown returns the markdown of the page of the platform.
+func (r *ZipRepository) Markdown(platform, page string) (io.ReadCloser, error) {
+	f, ok := r.index[platform][page]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: path.Join(pagesDirectory, platform, page+pageSuffix), Err: os.ErrNotExist}
+	}
+	return f.Open()
+}
+
+// Pages returns the names of the pages of all platforms.
+func (r *ZipRepository) Pages() ([]string, error) {
+	platforms, err := r.AvailablePlatforms()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, platform := range platforms {
+		pages := make([]string, 0, len(r.index[platform]))
+		for page := range r.index[platform] {
+			pages = append(pages, page)
+		}
+		sort.Strings(pages)
+		names = append(names, pages...)
+	}
+	return names, nil
+}
+
+// Reload replaces the archive with the one of the remote, like
+// Repository.Reload, and serves the pages from the new archive. The archive
+// is closed meanwhile, as open files can't be moved on every system, and
+// reopened if reloading fails.
+func (r *ZipRepository) Reload() error {
+	opened := r.reader != nil
+	err := r.Close()
+	if err != nil {
+		return fmt.Errorf("ERROR: closing zip: %s", err)
+	}
+
+	err = r.reload(validateArchive, validateArchive)
+	if err != nil {
+		if opened {
+			if openErr := r.open(); openErr != nil {
+				return fmt.Errorf("%s; %s", err, openErr)
+			}
+		}
+		return err
+	}
+	return r.open()
+}
+
+// Close closes the archive.
+func (r *ZipRepository) Close() error {
+	if r.reader == nil {
+		return nil
+	}
+	err := r.reader.Close()
+	r.reader = nil
+	r.index = nil
+	return err
+}
+
+// open opens the archive of the cache and indexes its pages. The archive
+// opened before is closed.
+func (r *ZipRepository) open() error {
+	reader, err := zip.OpenReader(r.directory + zipPath)
+	if err != nil {
+		return fmt.Errorf("ERROR: opening zip: %s", err)
+	}
+	index, err := indexArchive(&reader.Reader)
+	if err != nil {
+		reader.Close()
+		return err
+	}
+
+	r.Close()
+	r.reader = reader
+	r.index = index
+	return nil
+}
+
+// validateArchive checks that the archive in the directory holds pages.
+func validateArchive(dir string) error {
+	reader, err := zip.OpenReader(dir + zipPath)
+	if err != nil {
+		return fmt.Errorf("ERROR: validating downloaded pages: %s", err)
+	}
+	defer reader.Close()
+
+	_, err = indexArchive(&reader.Reader)
+	return err
+}
+
+// indexArchive returns the pages of the archive. Like extracting it, the
+// archive is rejected if it holds invalid paths or too many or too large
+// files. Entries other than regular files are skipped.
+func indexArchive(reader *zip.Reader) (archiveIndex, error) {
+	if len(reader.File) > maxExtractedFiles {
+		return nil, fmt.Errorf("ERROR: archive has more than %d files", maxExtractedFiles)
+	}
+
+	index := archiveIndex{}
+	var size uint64
+	found := false
+	for _, f := range reader.File {
+		name, err := entryPath(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		size += f.UncompressedSize64
+		if size > uint64(maxExtractedSize) {
+			return nil, fmt.Errorf("ERROR: archive exceeds %d bytes uncompressed", maxExtractedSize)
+		}
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		rest, ok := strings.CutPrefix(name, pagesDirectory+"/")
+		if !ok {
+			continue
+		}
+		platform, file, ok := strings.Cut(rest, "/")
+		if !ok || strings.Contains(file, "/") || !strings.HasSuffix(file, pageSuffix) {
+			continue
+		}
+		if index[platform] == nil {
+			index[platform] = map[string]*zip.File{}
+		}
+		index[platform][strings.TrimSuffix(file, pageSuffix)] = f
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("ERROR: validating downloaded pages: no pages found")
+	}
+	return index, nil
+}
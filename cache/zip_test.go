@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testPages = map[string]string{
+	"pages/index.json":       "{}",
+	"pages/common/cat.md":    "# cat\n",
+	"pages/common/tar.md":    "# common tar\n",
+	"pages/linux/tar.md":     "# tar\n",
+	"pages/linux/apt.md":     "# apt\n",
+	"pages/osx/brew.md":      "# brew\n",
+	"pages.de/common/cat.md": "# cat auf Deutsch\n",
+	"LICENSE.md":             "license",
+}
+
+// zipRepository returns a zip repository in a temporary directory loading
+// from a server answering with body.
+func zipRepository(t *testing.T, body []byte) *ZipRepository {
+	r := &ZipRepository{Repository: serverRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	})}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func readZipPage(t *testing.T, r *ZipRepository, platform, page string) string {
+	markdown, err := r.Markdown(platform, page)
+	require.NoError(t, err, "Markdown() error %v", err)
+	defer markdown.Close()
+
+	var content bytes.Buffer
+	_, err = content.ReadFrom(markdown)
+	require.NoError(t, err)
+	return content.String()
+}
+
+func TestZipRepositoryMatchesExtracted(t *testing.T) {
+	t.Parallel()
+	body := zipArchive(t, testPages)
+	extracted := serverRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	})
+	require.NoError(t, extracted.Reload())
+	zipped := zipRepository(t, body)
+	require.NoError(t, zipped.Reload())
+
+	platforms, err := zipped.AvailablePlatforms()
+	require.NoError(t, err, "AvailablePlatforms() error %v", err)
+	require.Equal(t, []string{"common", "linux", "osx"}, platforms)
+	extractedPlatforms, err := extracted.AvailablePlatforms()
+	require.NoError(t, err, "AvailablePlatforms() error %v", err)
+	require.Equal(t, extractedPlatforms, platforms)
+
+	pages, err := zipped.Pages()
+	require.NoError(t, err, "Pages() error %v", err)
+	require.Equal(t, []string{"cat", "tar", "apt", "tar", "brew"}, pages)
+	extractedPages, err := extracted.Pages()
+	require.NoError(t, err, "Pages() error %v", err)
+	require.Equal(t, extractedPages, pages)
+
+	for _, page := range []struct{ platform, name string }{{"common", "cat"}, {"common", "tar"}, {"linux", "tar"}, {"osx", "brew"}} {
+		require.Equal(t, readPage(t, extracted, page.platform, page.name), readZipPage(t, zipped, page.platform, page.name))
+	}
+
+	for _, page := range []struct{ platform, name string }{{"osx", "apt"}, {"windows", "cat"}, {"common", "index"}} {
+		_, err = zipped.Markdown(page.platform, page.name)
+		require.True(t, os.IsNotExist(err), "expected a missing page, got %v", err)
+		_, err = extracted.Markdown(page.platform, page.name)
+		require.True(t, os.IsNotExist(err), "expected a missing page, got %v", err)
+	}
+
+	// Only the archive is kept.
+	_, err = os.Stat(path.Join(zipped.directory, pagesDirectory))
+	require.True(t, os.IsNotExist(err), "expected no extracted pages")
+}
+
+func TestZipRepositoryReload(t *testing.T) {
+	t.Parallel()
+	body := zipArchive(t, map[string]string{"pages/common/cat.md": "# cat\n"})
+	r := zipRepository(t, body)
+	require.NoError(t, r.Reload())
+	require.NoError(t, r.RecordHistory("cat"))
+
+	body = zipArchive(t, map[string]string{"pages/common/cat.md": "# new cat\n"})
+	updated := serverRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	})
+	r.remote, r.checksum = updated.remote, updated.checksum
+
+	require.NoError(t, r.Reload())
+	require.Equal(t, "# new cat\n", readZipPage(t, r, "common", "cat"))
+	history, err := r.LoadHistory()
+	require.NoError(t, err, "LoadHistory() error %v", err)
+	require.Equal(t, []HistoryRecord{{page: "cat", count: 1}}, *history)
+	requireNoStaging(t, r.Repository)
+}
+
+func TestZipRepositoryKeepsArchiveOnFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{name: "invalid zip", body: []byte("<html>Service Unavailable</html>")},
+		{name: "empty zip", body: zipArchive(t, map[string]string{})},
+		{name: "no pages", body: zipArchive(t, map[string]string{"LICENSE.md": "license"})},
+		{name: "traversal", body: zipArchive(t, map[string]string{"pages/../../evil.md": "# evil\n"})},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := zipRepository(t, zipArchive(t, map[string]string{"pages/common/cat.md": "# old cat\n"}))
+			require.NoError(t, r.Reload())
+			require.NoError(t, r.RecordHistory("cat"))
+
+			failing := serverRepository(t, func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(tt.body)
+			})
+			r.remote, r.checksum = failing.remote, failing.checksum
+
+			require.Error(t, r.Reload())
+			require.Equal(t, "# old cat\n", readZipPage(t, r, "common", "cat"))
+			history, err := r.LoadHistory()
+			require.NoError(t, err, "LoadHistory() error %v", err)
+			require.Equal(t, []HistoryRecord{{page: "cat", count: 1}}, *history)
+			requireNoStaging(t, r.Repository)
+		})
+	}
+}
+
+func TestZipRepositoryReloadConditional(t *testing.T) {
+	t.Parallel()
+	body := zipArchive(t, map[string]string{"pages/common/cat.md": "# cat\n"})
+	downloads := 0
+	r := &ZipRepository{Repository: serverRepository(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write(body)
+	})}
+	t.Cleanup(func() { r.Close() })
+
+	require.NoError(t, r.Reload())
+	require.Equal(t, 1, downloads)
+
+	// A remote that didn't change only touches the cache.
+	stale := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(r.directory, stale, stale))
+	require.NoError(t, r.Reload())
+	require.Equal(t, 1, downloads)
+	require.Equal(t, "# cat\n", readZipPage(t, r, "common", "cat"))
+	info, err := os.Stat(r.directory)
+	require.NoError(t, err)
+	require.True(t, info.ModTime().After(stale.Add(time.Hour)), "expected the cache to be touched")
+
+	// Without the archive the version isn't sent.
+	require.NoError(t, r.Close())
+	require.NoError(t, os.Remove(r.directory+zipPath))
+	require.NoError(t, r.Reload())
+	require.Equal(t, 2, downloads)
+	require.Equal(t, "# cat\n", readZipPage(t, r, "common", "cat"))
+}
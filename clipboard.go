@@ -0,0 +1,26 @@
+package tldr
+
+import (
+	"encoding/base64"
+	"io"
+	"os"
+	"strings"
+)
+
+// WriteClipboard writes an OSC 52 escape sequence to the terminal, which
+// puts the text on its clipboard. This works over SSH as well, as long as the
+// terminal allows it. Inside tmux the sequence is passed through to the
+// outer terminal.
+func WriteClipboard(terminal io.Writer, text string) error {
+	_, err := io.WriteString(terminal, clipboardSequence(text, os.Getenv("TMUX") != ""))
+	return err
+}
+
+func clipboardSequence(text string, tmux bool) string {
+	sequence := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if tmux {
+		// Escape characters within the passthrough are doubled.
+		return "\x1bPtmux;" + strings.ReplaceAll(sequence, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return sequence
+}
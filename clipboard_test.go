@@ -0,0 +1,12 @@
+package tldr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClipboardSequence(t *testing.T) {
+	require.Equal(t, "\x1b]52;c;dGFyIC14Zg==\a", clipboardSequence("tar -xf", false))
+	require.Equal(t, "\x1bPtmux;\x1b\x1b]52;c;dGFyIC14Zg==\a\x1b\\", clipboardSequence("tar -xf", true))
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+// unifiedDiff returns the changes from a to b as unified diff of the file
+// with the given name.
+func unifiedDiff(name, a, b string) string {
+	before, after := splitLines(a), splitLines(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// before[i:] and after[j:].
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	// Every edit is a line prefixed by ' ', '-' or '+'.
+	var edits []string
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			edits = append(edits, " "+before[i])
+			i++
+			j++
+		case i < len(before) && (j == len(after) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, "-"+before[i])
+			i++
+		default:
+			edits = append(edits, "+"+after[j])
+			j++
+		}
+	}
+
+	var diff strings.Builder
+	diff.WriteString("--- " + name + "\n+++ " + name + "\n")
+	// line numbers before and after the current edit
+	oldLine, newLine := 1, 1
+	for start := 0; start < len(edits); {
+		if edits[start][0] == ' ' {
+			start++
+			oldLine++
+			newLine++
+			continue
+		}
+
+		// A hunk starts with the context of the first change and ends when
+		// there are more unchanged lines than context around changes.
+		first := max(start-diffContext, 0)
+		end := start
+		for unchanged := 0; end < len(edits) && unchanged <= 2*diffContext; end++ {
+			if edits[end][0] == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		last := end
+		for last > start && edits[last-1][0] == ' ' {
+			last--
+		}
+		last = min(last+diffContext, len(edits))
+
+		oldStart, newStart := oldLine-(start-first), newLine-(start-first)
+		var oldCount, newCount int
+		var hunk strings.Builder
+		for _, edit := range edits[first:last] {
+			if edit[0] != '+' {
+				oldCount++
+			}
+			if edit[0] != '-' {
+				newCount++
+			}
+			hunk.WriteString(edit + "\n")
+		}
+		fmt.Fprintf(&diff, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		diff.WriteString(hunk.String())
+
+		for _, edit := range edits[start:last] {
+			if edit[0] != '+' {
+				oldLine++
+			}
+			if edit[0] != '-' {
+				newLine++
+			}
+		}
+		start = last
+	}
+	return diff.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// An empty range refers to the line before it.
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits the text into lines, marking a missing final newline
+// like diff does.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n\\ No newline at end of file"
+	return lines
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mstruebing/tldr"
+)
+
+// Help message constants of the export command
+const (
+	exportFormatUsage = "export format; supported are: html, man"
+	exportOutUsage    = "directory the pages are written to"
+)
+
+const htmlHeader = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+<link rel="stylesheet" href="%s">
+</head>
+<body>
+`
+
+const htmlFooter = `</body>
+</html>
+`
+
+const stylesheet = `body { font-family: sans-serif; max-width: 50em; margin: 0 auto; padding: 1em; }
+nav { margin-bottom: 1em; }
+.description { margin: 0.2em 0; }
+.examples { list-style: none; padding: 0; }
+.example-description { color: #2e7d32; margin-bottom: 0.2em; }
+.command { display: block; background: #f5f5f5; padding: 0.5em; color: #c62828; }
+.placeholder { color: #1565c0; font-style: normal; }
+.index ul { columns: 4 10em; }
+`
+
+// exportOptions are the flags of `tldr export`.
+type exportOptions struct {
+	format string
+	outDir string
+}
+
+func (o *exportOptions) flagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	flags.StringVar(&o.format, "format", formatHTML, exportFormatUsage)
+	flags.StringVar(&o.outDir, "out", "", exportOutUsage)
+	return flags
+}
+
+// exportCommand runs `tldr export`, which writes all pages to a directory.
+func exportCommand(args []string) {
+	var opts exportOptions
+	flags := opts.flagSet()
+	flags.Parse(args)
+
+	if opts.outDir == "" {
+		log.Fatal("ERROR: no output directory provided")
+	}
+	if opts.format != formatHTML && opts.format != formatMan {
+		log.Fatalf("ERROR: unsupported export format '%s'", opts.format)
+	}
+	exportPages(opts.format, opts.outDir, tldr.OptionFormBoth)
+}
+
+func exportPages(format, dir string, form tldr.OptionForm) {
+	repository, err := newRepository()
+	if err != nil {
+		log.Fatalf("ERROR: creating cache repository: %s", err)
+	}
+
+	pages, err := repository.Pages()
+	if err != nil {
+		log.Fatalf("ERROR: getting pages: %s", err)
+	}
+	pages = uniquePages(pages)
+
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		log.Fatalf("ERROR: creating directory '%s': %s", dir, err)
+	}
+
+	if format == formatMan {
+		err = writeManPages(repository, pages, dir, form)
+	} else {
+		err = writeHTMLPages(repository, pages, dir, form)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("exported %d pages to %s\n", len(pages), dir)
+}
+
+func uniquePages(pages []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, page := range pages {
+		if !seen[page] {
+			seen[page] = true
+			unique = append(unique, page)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+func writeManPages(repository tldr.Repository, pages []string, dir string, form tldr.OptionForm) error {
+	for _, page := range pages {
+		err := writeManPage(repository, page, filepath.Join(dir, page+".1"), form)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeManPage(repository tldr.Repository, page, path string, form tldr.OptionForm) error {
+	markdown, _, err := findPage(repository, page)
+	if err != nil {
+		return err
+	}
+	defer markdown.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("ERROR: creating man page '%s': %s", path, err)
+	}
+	defer file.Close()
+
+	err = tldr.WriteMan(markdown, file, form)
+	if err != nil {
+		return fmt.Errorf("ERROR: writing man page '%s': %s", path, err)
+	}
+	return nil
+}
+
+// writeHTMLPages writes every page to <dir>/<platform>/<page>.html and an
+// index of all pages grouped by platform to <dir>/index.html.
+func writeHTMLPages(repository tldr.Repository, pages []string, dir string, form tldr.OptionForm) error {
+	platforms, err := repository.AvailablePlatforms()
+	if err != nil {
+		return fmt.Errorf("ERROR: getting available platforms: %s", err)
+	}
+	sort.Strings(platforms)
+
+	parsed := map[string]map[string]*tldr.Page{}
+	for _, platform := range platforms {
+		parsed[platform] = map[string]*tldr.Page{}
+		for _, name := range pages {
+			markdown, err := repository.Markdown(platform, name)
+			if err != nil {
+				continue
+			}
+			page, err := tldr.Parse(markdown)
+			markdown.Close()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "WARNING: skipping page '%s/%s': %s\n", platform, name, err)
+				continue
+			}
+			for _, warning := range page.Warnings {
+				fmt.Fprintf(os.Stderr, "WARNING: page '%s/%s': %s\n", platform, name, warning)
+			}
+			parsed[platform][name] = page
+		}
+	}
+
+	for _, platform := range platforms {
+		err = os.MkdirAll(filepath.Join(dir, platform), 0755)
+		if err != nil {
+			return fmt.Errorf("ERROR: creating directory for '%s': %s", platform, err)
+		}
+
+		link := func(name string) string {
+			if _, ok := parsed[platform][name]; ok {
+				return name + ".html"
+			}
+			if _, ok := parsed[tldr.CommonPlatform][name]; ok {
+				return "../" + tldr.CommonPlatform + "/" + name + ".html"
+			}
+			for _, other := range platforms {
+				if _, ok := parsed[other][name]; ok {
+					return "../" + other + "/" + name + ".html"
+				}
+			}
+			return ""
+		}
+
+		for name, page := range parsed[platform] {
+			content := fmt.Sprintf(htmlHeader, html.EscapeString(page.Name), "../style.css")
+			content += "<nav><a href=\"../index.html\">All pages</a> / " + html.EscapeString(platform) + "</nav>\n"
+			content += tldr.RenderHTML(page, form, link) + htmlFooter
+			err = writeFile(filepath.Join(dir, platform, name+".html"), content)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var index strings.Builder
+	fmt.Fprintf(&index, htmlHeader, "tldr pages", "style.css")
+	index.WriteString("<main class=\"index\">\n<h1>tldr pages</h1>\n")
+	for _, platform := range platforms {
+		if len(parsed[platform]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&index, "<section id=\"%s\">\n<h2>%s</h2>\n<ul>\n", html.EscapeString(platform), html.EscapeString(platform))
+		for _, name := range pages {
+			if _, ok := parsed[platform][name]; ok {
+				fmt.Fprintf(&index, "<li><a href=\"%s\">%s</a></li>\n", html.EscapeString(platform+"/"+name+".html"), html.EscapeString(name))
+			}
+		}
+		index.WriteString("</ul>\n</section>\n")
+	}
+	index.WriteString("</main>\n" + htmlFooter)
+
+	err = writeFile(filepath.Join(dir, "index.html"), index.String())
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, "style.css"), stylesheet)
+}
+
+func writeFile(path, content string) error {
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		return fmt.Errorf("ERROR: writing '%s': %s", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mstruebing/tldr"
+)
+
+// Help message constants of the fmt command
+const (
+	fmtCheckUsage = "list pages that aren't formatted instead of rewriting them; exits non-zero if there are any"
+	fmtDiffUsage  = "print the changes as unified diff instead of rewriting the pages"
+)
+
+// fmtOptions are the flags of `tldr fmt`.
+type fmtOptions struct {
+	check bool
+	diff  bool
+}
+
+func (o *fmtOptions) flagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	flags.BoolVar(&o.check, "check", false, fmtCheckUsage)
+	flags.BoolVar(&o.diff, "diff", false, fmtDiffUsage)
+	return flags
+}
+
+// fmtCommand runs `tldr fmt`, which rewrites pages into their canonical form.
+func fmtCommand(args []string) {
+	var opts fmtOptions
+	flags := opts.flagSet()
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		log.Fatal("ERROR: no page provided")
+	}
+
+	var unformatted bool
+	for _, path := range flags.Args() {
+		files, err := pageFiles(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, file := range files {
+			original, err := os.ReadFile(file)
+			if err != nil {
+				log.Fatalf("ERROR: reading '%s': %s", file, err)
+			}
+			formatted, err := tldr.Format(bytes.NewReader(original))
+			if err != nil {
+				log.Fatalf("ERROR: formatting '%s': %s", file, err)
+			}
+			if formatted == string(original) {
+				continue
+			}
+			unformatted = true
+
+			switch {
+			case opts.check:
+				fmt.Println(file)
+			case opts.diff:
+				fmt.Print(unifiedDiff(file, string(original), formatted))
+			default:
+				err = os.WriteFile(file, []byte(formatted), 0644)
+				if err != nil {
+					log.Fatalf("ERROR: writing '%s': %s", file, err)
+				}
+			}
+		}
+	}
+
+	if opts.check && unformatted {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/mstruebing/tldr"
+)
+
+// interactive lets the user pick an example of the page and fill in its
+// placeholders. The completed command is printed, or run through $SHELL if
+// run is set. The examples and prompts are written to stderr, so the command
+// can be captured.
+func interactive(page string, platform string, form tldr.OptionForm, run bool) {
+	if page == "" {
+		log.Fatal("ERROR: no page provided")
+	}
+
+	repository, err := newRepository()
+	if err != nil {
+		log.Fatalf("ERROR: creating cache repository: %s", err)
+	}
+
+	var markdown io.ReadCloser
+	if platform != "" {
+		markdown, err = repository.Markdown(platform, page)
+		if err != nil {
+			log.Fatalf("ERROR: getting markdown for '%s/%s': %s", platform, page, err)
+		}
+	} else {
+		markdown, _, err = findPage(repository, page)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	parsed, err := tldr.Parse(markdown)
+	markdown.Close()
+	if err != nil {
+		log.Fatalf("ERROR: parsing the page: %s", err)
+	}
+
+	err = repository.RecordHistory(page)
+	if err != nil {
+		log.Fatalf("ERROR: saving history: %s", err)
+	}
+
+	command, err := completeCommand(parsed, form, bufio.NewReader(os.Stdin), os.Stderr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !run {
+		fmt.Println(command)
+		return
+	}
+
+	err = runCommand(command)
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		os.Exit(exitErr.ExitCode())
+	} else if err != nil {
+		log.Fatalf("ERROR: running the command: %s", err)
+	}
+}
+
+// completeCommand lists the examples of the page, asks for the number of one
+// and for the values of its placeholders, and returns the completed command.
+// An empty value keeps the placeholder text. Options are given in their long
+// form unless the short one is chosen, see commandForm.
+func completeCommand(page *tldr.Page, form tldr.OptionForm, in *bufio.Reader, prompt io.Writer) (string, error) {
+	form = commandForm(form)
+	if len(page.Examples) == 0 {
+		return "", fmt.Errorf("ERROR: page '%s' has no examples", page.Name)
+	}
+	for i, example := range page.Examples {
+		fmt.Fprintf(prompt, "%d. %s\n   %s\n", i+1, example.Description, example.Fill(nil, form))
+	}
+
+	var example tldr.Example
+	for {
+		fmt.Fprintf(prompt, "Example [1-%d]: ", len(page.Examples))
+		answer, err := readAnswer(in)
+		if err != nil {
+			return "", err
+		}
+		number, err := strconv.Atoi(answer)
+		if err == nil && number >= 1 && number <= len(page.Examples) {
+			example = page.Examples[number-1]
+			break
+		}
+		fmt.Fprintf(prompt, "'%s' is not the number of an example\n", answer)
+	}
+
+	var values []string
+	for _, placeholder := range example.Placeholders() {
+		fmt.Fprintf(prompt, "Value of {{%s}} [%s]: ", placeholder, placeholder)
+		value, err := readAnswer(in)
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			value = placeholder
+		}
+		values = append(values, value)
+	}
+	return example.Fill(values, form), nil
+}
+
+// commandForm returns the form of options in commands that are run or
+// copied. Both forms together aren't a valid command, so the long form is
+// used instead.
+func commandForm(form tldr.OptionForm) tldr.OptionForm {
+	if form == tldr.OptionFormBoth {
+		return tldr.OptionFormLong
+	}
+	return form
+}
+
+// readAnswer reads a line of input without its surrounding whitespace. The
+// last line doesn't need to end with a newline.
+func readAnswer(in *bufio.Reader) (string, error) {
+	line, err := in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("ERROR: reading input: %s", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// runCommand runs the command through the shell of the user, /bin/sh if
+// $SHELL isn't set.
+func runCommand(command string) error {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	cmd := exec.Command(shell, "-c", command)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
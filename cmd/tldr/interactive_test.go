@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mstruebing/tldr"
+	"github.com/stretchr/testify/require"
+)
+
+const tarPage = "# tar\n\n> Archiving utility.\n\n- Create an archive:\n\n`tar {{[-c|--create]}} -f {{target.tar}} {{path/to/file}}`\n\n- List the contents:\n\n`tar -tf {{source.tar}}`\n"
+
+func TestCompleteCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		form  tldr.OptionForm
+		input string
+		want  string
+		err   string
+	}{
+		{name: "values", input: "1\nbackup.tar\nnotes.txt\n", want: "tar --create -f backup.tar notes.txt"},
+		{name: "short options", form: tldr.OptionFormShort, input: "1\nbackup.tar\nnotes.txt\n", want: "tar -c -f backup.tar notes.txt"},
+		{name: "defaults", input: "2\n\n", want: "tar -tf source.tar"},
+		{name: "no final newline", input: "2\nbackup.tar", want: "tar -tf backup.tar"},
+		{name: "invalid number", input: "0\nlist\n2\n\n", want: "tar -tf source.tar"},
+		{name: "no input", input: "", err: "ERROR: reading input: EOF"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			page, err := tldr.Parse(strings.NewReader(tarPage))
+			require.NoError(t, err, "Parse() error %v", err)
+
+			var prompt bytes.Buffer
+			command, err := completeCommand(page, tt.form, bufio.NewReader(strings.NewReader(tt.input)), &prompt)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err, "completeCommand() error %v", err)
+			require.Equal(t, tt.want, command)
+			if tt.form == tldr.OptionFormBoth {
+				require.Contains(t, prompt.String(), "1. Create an archive:\n   tar --create -f target.tar path/to/file\n")
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mstruebing/tldr"
+)
+
+const lintJSONUsage = "print the diagnostics as JSON"
+
+// fileDiagnostic is a diagnostic of a linted file.
+type fileDiagnostic struct {
+	File string `json:"file"`
+	tldr.Diagnostic
+}
+
+// lintOptions are the flags of `tldr lint`.
+type lintOptions struct {
+	json bool
+}
+
+func (o *lintOptions) flagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	flags.BoolVar(&o.json, "json", false, lintJSONUsage)
+	return flags
+}
+
+// lintCommand runs `tldr lint`, which checks pages against the tldr-pages
+// style rules and exits with a non-zero status if any rule is violated.
+func lintCommand(args []string) {
+	var opts lintOptions
+	flags := opts.flagSet()
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		log.Fatal("ERROR: no page provided")
+	}
+
+	diagnostics := []fileDiagnostic{}
+	for _, path := range flags.Args() {
+		files, err := pageFiles(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, file := range files {
+			found, err := lintFile(file)
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, diagnostic := range found {
+				diagnostics = append(diagnostics, fileDiagnostic{File: file, Diagnostic: diagnostic})
+			}
+		}
+	}
+
+	if opts.json {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diagnostics); err != nil {
+			log.Fatalf("ERROR: writing diagnostics: %s", err)
+		}
+	} else {
+		for _, diagnostic := range diagnostics {
+			fmt.Printf("%s:%s\n", diagnostic.File, diagnostic.Diagnostic)
+		}
+	}
+
+	if len(diagnostics) > 0 {
+		os.Exit(1)
+	}
+}
+
+// pageFiles returns the path if it is a file, or all markdown files in it if
+// it is a directory.
+func pageFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: %s", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.Walk(path, func(file string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !f.IsDir() && strings.HasSuffix(f.Name(), ".md") {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: reading pages in '%s': %s", path, err)
+	}
+	return files, nil
+}
+
+func lintFile(path string) ([]tldr.Diagnostic, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: opening '%s': %s", path, err)
+	}
+	defer file.Close()
+
+	diagnostics, err := tldr.Lint(file)
+	if err != nil {
+		return nil, fmt.Errorf("ERROR: linting '%s': %s", path, err)
+	}
+	return diagnostics, nil
+}
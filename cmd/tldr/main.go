@@ -2,17 +2,23 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"math/rand"
 	"os"
+	"regexp"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/mstruebing/tldr"
 	"github.com/mstruebing/tldr/cache"
+	"github.com/mstruebing/tldr/config"
 )
 
 // Help message constants
@@ -24,6 +30,31 @@ const (
 	versionUsage  = "print version and exit"
 	randomUsage   = "prints a random page"
 	historyUsage  = "show the latest search history"
+	outUsage      = "write man pages of all pages to the given directory; requires --format man"
+	formatUsage   = "output format; supported are: text, json, man, markdown"
+	rawUsage      = "print the markdown of the page unchanged; same as --format markdown"
+	sourceUsage   = "prefix raw markdown with a comment naming the chosen platform and language"
+	colorUsage    = "colorize the output; supported are: auto, always, never"
+	themeUsage    = "select the color theme; built-in are: default, light, dark, monochrome"
+	widthUsage    = "wrap descriptions at the given width; defaults to the terminal width, -1 disables wrapping"
+	shortUsage    = "display options in their short form"
+	longUsage     = "display options in their long form"
+	compactUsage  = "print every example on a single line, without blank lines"
+	interactUsage = "pick an example of the page, fill in its placeholders and print the command"
+	execUsage     = "run the command completed with --interactive through $SHELL"
+	copyUsage     = "number the examples and copy the command of the given one to the terminal clipboard"
+	stripUsage    = "remove the braces around placeholders of the command copied with --copy"
+	noPagerUsage  = "never show long pages in $PAGER"
+	grepUsage     = "show only the examples matching the regular expression or text, ignoring case"
+)
+
+// Output formats
+const (
+	formatText     = "text"
+	formatJSON     = "json"
+	formatMan      = "man"
+	formatHTML     = "html"
+	formatMarkdown = "markdown"
 )
 
 const (
@@ -33,6 +64,142 @@ const (
 
 const currentPlattform = runtime.GOOS
 
+// output holds the settings controlling how pages are printed.
+type output struct {
+	format string
+	render tldr.Options
+	// source prefixes raw markdown with the platform and language it is
+	// taken from.
+	source bool
+	// copy is the number of the example copied to the clipboard, or zero.
+	copy int
+	// strip removes the braces around the placeholders of the copied
+	// command.
+	strip bool
+	// pager shows output longer than the terminal in a pager.
+	pager bool
+}
+
+func (o output) write(markdown io.Reader, platform string) error {
+	paged := o.pager && tldr.IsTerminal(os.Stdout)
+	if o.copy == 0 && !paged {
+		return o.writeFormat(os.Stdout, markdown, platform)
+	}
+
+	content, err := io.ReadAll(markdown)
+	if err != nil {
+		return err
+	}
+	if paged {
+		err = o.page(content, platform)
+	} else {
+		err = o.writeFormat(os.Stdout, bytes.NewReader(content), platform)
+	}
+	if err != nil || o.copy == 0 {
+		return err
+	}
+	return o.copyExample(bytes.NewReader(content))
+}
+
+func (o output) writeFormat(dest io.Writer, markdown io.Reader, platform string) error {
+	switch o.format {
+	case formatMarkdown:
+		if o.source && platform != "" {
+			fmt.Fprintf(dest, "<!-- platform: %s, language: %s -->\n", platform, tldr.DefaultLanguage)
+		}
+		_, err := io.Copy(dest, markdown)
+		return err
+	case formatJSON:
+		return tldr.WriteJSON(markdown, dest, platform, tldr.DefaultLanguage, o.render.OptionForm)
+	case formatMan:
+		return tldr.WriteMan(markdown, dest, o.render.OptionForm)
+	default:
+		return tldr.WriteWithOptions(markdown, dest, o.render)
+	}
+}
+
+// repository is the cache the pages are read from.
+type repository interface {
+	tldr.Repository
+	Reload() error
+	RecordHistory(page string) error
+	LoadHistory() (*[]cache.HistoryRecord, error)
+}
+
+// newRepository returns the cache repository, configured by the config
+// file.
+func newRepository() (repository, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	opts := cache.Options{ChecksumURL: cfg.ChecksumURL}
+	switch cfg.Cache {
+	case "", config.CacheExtracted:
+		repo, err := cache.NewRepositoryWithOptions(remoteURL, ttl, opts)
+		if err != nil {
+			return nil, err
+		}
+		return repo, nil
+	case config.CacheZip:
+		repo, err := cache.NewZipRepository(remoteURL, ttl, opts)
+		if err != nil {
+			return nil, err
+		}
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("ERROR: unknown cache '%s'; supported are: %s, %s", cfg.Cache, config.CacheExtracted, config.CacheZip)
+	}
+}
+
+// grepPattern compiles the pattern of --grep, a pattern that isn't a valid
+// regular expression is matched literally.
+func grepPattern(pattern string) *regexp.Regexp {
+	grep, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return regexp.MustCompile("(?i)" + regexp.QuoteMeta(pattern))
+	}
+	return grep
+}
+
+// copiedCommand returns the command of the example as it is copied: as in
+// the page, or without placeholder markers and with options in a single form
+// if strip is set.
+func (o output) copiedCommand(example tldr.Example) string {
+	if o.strip {
+		return example.Fill(nil, commandForm(o.render.OptionForm))
+	}
+	return example.CommandMarkdown()
+}
+
+// copyExample puts the command of the chosen example on the clipboard of the
+// terminal.
+func (o output) copyExample(markdown io.Reader) error {
+	page, err := tldr.Parse(markdown)
+	if err != nil {
+		return err
+	}
+	if o.copy < 1 || o.copy > len(page.Examples) {
+		return fmt.Errorf("ERROR: page '%s' has no example %d", page.Name, o.copy)
+	}
+
+	command := o.copiedCommand(page.Examples[o.copy-1])
+
+	terminal := os.Stdout
+	if !tldr.IsTerminal(terminal) {
+		terminal = os.Stderr
+	}
+	if !tldr.IsTerminal(terminal) {
+		return errors.New("ERROR: --copy requires a terminal")
+	}
+	err = tldr.WriteClipboard(terminal, command)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Copied example %d to the clipboard\n", o.copy)
+	return nil
+}
+
 func printVersion() {
 	fmt.Println("tldr v 1.3.1")
 	fmt.Println("Copyright (C) 2017 Max Strübing")
@@ -40,7 +207,7 @@ func printVersion() {
 }
 
 func listAllPages() {
-	repository, err := cache.NewRepository(remoteURL, ttl)
+	repository, err := newRepository()
 	if err != nil {
 		log.Fatalf("ERROR: creating repository: %s", err)
 	}
@@ -55,7 +222,7 @@ func listAllPages() {
 	}
 }
 
-func printPageInPath(path string) {
+func printPageInPath(path string, out output) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		log.Fatal("ERROR: page doesn't exist")
 	}
@@ -66,61 +233,78 @@ func printPageInPath(path string) {
 	}
 	defer page.Close()
 
-	err = tldr.Write(page, os.Stdout)
-	if err != nil {
+	// Pages in the cache are checked upstream, a page being written isn't.
+	out.render.Warn = func(warning *tldr.ParseError) {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", warning)
+	}
+	err = out.write(page, "")
+	if errors.Is(err, tldr.ErrNoMatch) {
+		log.Fatal(err)
+	} else if err != nil {
 		log.Fatalf("ERROR: rendering the page: %s", err)
 	}
 }
 
-func printPage(page string) {
+func printPage(page string, out output) {
 	if page == "" {
 		flag.PrintDefaults()
 		os.Exit(0)
 	}
 
-	repository, err := cache.NewRepository(remoteURL, ttl)
+	repository, err := newRepository()
 	if err != nil {
 		log.Fatalf("ERROR: creating cache repository: %s", err)
 	}
 
-	platform := tldr.CurrentPlatform(currentPlattform)
-	markdown, err := repository.Markdown(platform, page)
+	markdown, platform, err := findPage(repository, page)
 	if err != nil {
-		var platforms []string
-		platforms, err = tldr.AvailablePlatforms(repository, currentPlattform)
-		if err != nil {
-			log.Fatalf("ERROR: getting available platforms: %s", err)
-		}
-
-		for _, platform = range platforms {
-			markdown, err = repository.Markdown(platform, page)
-			if err == nil {
-				break
-			}
-		}
-		if err != nil {
-			log.Fatalf("ERROR: no page found for '%s' in any available platform", page)
-		}
+		log.Fatal(err)
 	}
 	defer markdown.Close()
 
-	err = tldr.Write(markdown, os.Stdout)
-	if err != nil {
-		log.Fatalf("ERROR: writing markdown: %s", err)
+	writeErr := out.write(markdown, platform)
+	if writeErr != nil && !errors.Is(writeErr, tldr.ErrNoMatch) {
+		log.Fatalf("ERROR: writing markdown: %s", writeErr)
 	}
 
 	err = repository.RecordHistory(page)
 	if err != nil {
 		log.Fatalf("ERROR: saving history: %s", err)
 	}
+	if writeErr != nil {
+		log.Fatal(writeErr)
+	}
 }
 
-func printPageForPlatform(page string, platform string) {
+// findPage returns the markdown of the page for the current platform, or for
+// the first available platform having the page.
+func findPage(repository tldr.Repository, page string) (io.ReadCloser, string, error) {
+	platform := tldr.CurrentPlatform(currentPlattform)
+	markdown, err := repository.Markdown(platform, page)
+	if err == nil {
+		return markdown, platform, nil
+	}
+
+	platforms, err := tldr.AvailablePlatforms(repository, currentPlattform)
+	if err != nil {
+		return nil, "", fmt.Errorf("ERROR: getting available platforms: %s", err)
+	}
+
+	for _, platform = range platforms {
+		markdown, err = repository.Markdown(platform, page)
+		if err == nil {
+			return markdown, platform, nil
+		}
+	}
+	return nil, "", fmt.Errorf("ERROR: no page found for '%s' in any available platform", page)
+}
+
+func printPageForPlatform(page string, platform string, out output) {
 	if page == "" {
 		log.Fatal("ERROR: no page provided")
 	}
 
-	repository, err := cache.NewRepository(remoteURL, ttl)
+	repository, err := newRepository()
 	if err != nil {
 		log.Fatalf("ERROR: creating cache repository: %s", err)
 	}
@@ -131,14 +315,16 @@ func printPageForPlatform(page string, platform string) {
 	}
 	defer markdown.Close()
 
-	err = tldr.Write(markdown, os.Stdout)
-	if err != nil {
+	err = out.write(markdown, platform)
+	if errors.Is(err, tldr.ErrNoMatch) {
+		log.Fatal(err)
+	} else if err != nil {
 		log.Fatalf("ERROR: writing markdown: %s", err)
 	}
 }
 
-func printRandomPage() {
-	repository, err := cache.NewRepository(remoteURL, ttl)
+func printRandomPage(out output) {
+	repository, err := newRepository()
 	if err != nil {
 		log.Fatalf("ERROR: creating cache repository: %s", err)
 	}
@@ -149,11 +335,11 @@ func printRandomPage() {
 	}
 	s := rand.NewSource(time.Now().Unix())
 	r := rand.New(s) // initialize local pseudorandom generator
-	printPage(pages[r.Intn(len(pages))])
+	printPage(pages[r.Intn(len(pages))], out)
 }
 
 func updatePages() {
-	repository, err := cache.NewRepository(remoteURL, ttl)
+	repository, err := newRepository()
 	if err != nil {
 		log.Fatalf("ERROR: creating cache repository: %s", err)
 	}
@@ -164,7 +350,7 @@ func updatePages() {
 }
 
 func printHistory() {
-	repository, err := cache.NewRepository(remoteURL, ttl)
+	repository, err := newRepository()
 	if err != nil {
 		log.Fatalf("ERROR: creating cache repository: %s", err)
 	}
@@ -186,7 +372,46 @@ func printHistory() {
 	}
 }
 
+// subcommand is a command run by its name given as first argument.
+type subcommand struct {
+	// flagSet returns the flags of the command.
+	flagSet func() *flag.FlagSet
+	run     func(args []string)
+}
+
+var subcommands = map[string]subcommand{
+	"export": {flagSet: func() *flag.FlagSet { return new(exportOptions).flagSet() }, run: exportCommand},
+	"lint":   {flagSet: func() *flag.FlagSet { return new(lintOptions).flagSet() }, run: lintCommand},
+	"fmt":    {flagSet: func() *flag.FlagSet { return new(fmtOptions).flagSet() }, run: fmtCommand},
+}
+
+// findSubcommand returns the subcommand the arguments, without the program
+// name, call for. As there are pages of the same names, a subcommand is only
+// run if it is followed by one of its flags or a path, so `tldr fmt` and
+// `tldr fmt --copy 1` still show the page.
+func findSubcommand(args []string) (subcommand, bool) {
+	if len(args) < 2 {
+		return subcommand{}, false
+	}
+	cmd, ok := subcommands[args[0]]
+	if !ok {
+		return subcommand{}, false
+	}
+
+	next := args[1]
+	if !strings.HasPrefix(next, "-") || next == "-" {
+		return cmd, true
+	}
+	name, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(next, "-"), "-"), "=")
+	return cmd, cmd.flagSet().Lookup(name) != nil
+}
+
 func main() {
+	if cmd, ok := findSubcommand(os.Args[1:]); ok {
+		cmd.run(os.Args[2:])
+		return
+	}
+
 	version := flag.Bool("version", false, versionUsage)
 	flag.BoolVar(version, "v", false, versionUsage)
 
@@ -209,25 +434,110 @@ func main() {
 	history := flag.Bool("history", false, historyUsage)
 	flag.BoolVar(history, "t", false, historyUsage)
 
+	format := flag.String("format", formatText, formatUsage)
+	raw := flag.Bool("raw", false, rawUsage)
+	source := flag.Bool("source", false, sourceUsage)
+	outDir := flag.String("out", "", outUsage)
+	color := flag.String("color", "auto", colorUsage)
+	themeName := flag.String("theme", "", themeUsage)
+	width := flag.Int("width", 0, widthUsage)
+	shortOptions := flag.Bool("short-options", false, shortUsage)
+	longOptions := flag.Bool("long-options", false, longUsage)
+	compact := flag.Bool("compact", false, compactUsage)
+	grep := flag.String("grep", "", grepUsage)
+	interact := flag.Bool("interactive", false, interactUsage)
+	flag.BoolVar(interact, "i", false, interactUsage)
+	execute := flag.Bool("exec", false, execUsage)
+	copyExample := flag.Int("copy", 0, copyUsage)
+	strip := flag.Bool("strip-placeholders", false, stripUsage)
+	noPager := flag.Bool("no-pager", false, noPagerUsage)
+
 	flag.Parse()
+	page := flag.Arg(0)
+	// Flags may also follow the page name, as in "tldr tar --copy 3".
+	for flag.NArg() > 0 {
+		_ = flag.CommandLine.Parse(flag.Args()[1:])
+	}
+
+	if *raw {
+		*format = formatMarkdown
+	}
+	switch *format {
+	case formatText, formatJSON, formatMan, formatMarkdown:
+	default:
+		log.Fatalf("ERROR: unsupported format '%s'", *format)
+	}
+	colorMode, err := tldr.ParseColorMode(*color)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	theme, err := cfg.LookupTheme(*themeName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	optionForm := tldr.OptionFormBoth
+	if *shortOptions && !*longOptions {
+		optionForm = tldr.OptionFormShort
+	} else if *longOptions && !*shortOptions {
+		optionForm = tldr.OptionFormLong
+	}
+	layout := tldr.LayoutDefault
+	if *compact {
+		layout = tldr.LayoutCompact
+	}
+	render := tldr.Options{Color: colorMode, Theme: &theme, Width: *width, OptionForm: optionForm, Layout: layout}
+	if *grep != "" {
+		render.Grep = grepPattern(*grep)
+	}
+	if *copyExample != 0 {
+		render.Numbered = true
+	}
+	out := output{
+		format: *format,
+		render: render,
+		source: *source,
+		copy:   *copyExample,
+		strip:  *strip,
+		pager:  !*noPager && cfg.PagerEnabled(),
+	}
+
+	if *outDir != "" && *format != formatMan {
+		log.Fatal("ERROR: --out requires --format man")
+	}
+	if *grep != "" && *format != formatText {
+		log.Fatal("ERROR: --grep requires --format text")
+	}
+	if *strip && *copyExample == 0 {
+		log.Fatal("ERROR: --strip-placeholders requires --copy")
+	}
+	if *execute && !*interact {
+		log.Fatal("ERROR: --exec requires --interactive")
+	}
 
 	if *version {
 		printVersion()
+	} else if *outDir != "" {
+		exportPages(formatMan, *outDir, out.render.OptionForm)
 	} else if *update {
 		updatePages()
 	} else if *path != "" {
-		printPageInPath(*path)
+		printPageInPath(*path, out)
 	} else if *listAll {
 		listAllPages()
+	} else if *interact {
+		interactive(page, *platform, optionForm, *execute)
 	} else if *platform != "" {
-		page := flag.Arg(0)
-		printPageForPlatform(page, *platform)
+		printPageForPlatform(page, *platform, out)
 	} else if *random {
-		printRandomPage()
+		printRandomPage(out)
 	} else if *history {
 		printHistory()
 	} else {
-		page := flag.Arg(0)
-		printPage(page)
+		printPage(page, out)
 	}
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mstruebing/tldr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrepPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		want    bool
+	}{
+		{pattern: "remove", text: "Remove a container:", want: true},
+		{pattern: "rm|remove", text: "docker rm {{container}}", want: true},
+		{pattern: "remove(", text: "git remove(", want: true},
+		{pattern: "remove(", text: "git remove", want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.pattern+" "+tt.text, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, grepPattern(tt.pattern).MatchString(tt.text))
+		})
+	}
+}
+
+func TestFindSubcommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{args: []string{"fmt"}, want: false},
+		{args: []string{"fmt", "pages/common/tar.md"}, want: true},
+		{args: []string{"fmt", "--check", "pages"}, want: true},
+		{args: []string{"fmt", "-diff", "pages"}, want: true},
+		{args: []string{"fmt", "--copy", "1"}, want: false},
+		{args: []string{"export", "--format=man", "--out", "man"}, want: true},
+		{args: []string{"export", "--raw"}, want: false},
+		{args: []string{"lint", "--json", "pages"}, want: true},
+		{args: []string{"lint", "-p", "linux"}, want: false},
+		{args: []string{"tar", "pages"}, want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			t.Parallel()
+			_, ok := findSubcommand(tt.args)
+			require.Equal(t, tt.want, ok)
+		})
+	}
+}
+
+func TestCopiedCommand(t *testing.T) {
+	page, err := tldr.Parse(strings.NewReader(tarPage))
+	require.NoError(t, err, "Parse() error %v", err)
+	example := page.Examples[0]
+
+	tests := []struct {
+		name string
+		out  output
+		want string
+	}{
+		{name: "markdown", out: output{}, want: "tar {{[-c|--create]}} -f {{target.tar}} {{path/to/file}}"},
+		{name: "stripped", out: output{strip: true}, want: "tar --create -f target.tar path/to/file"},
+		{name: "stripped short", out: output{strip: true, render: tldr.Options{OptionForm: tldr.OptionFormShort}}, want: "tar -c -f target.tar path/to/file"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, tt.out.copiedCommand(example))
+		})
+	}
+}
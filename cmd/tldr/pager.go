@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+
+	"github.com/mstruebing/tldr"
+)
+
+const defaultPager = "less -R"
+
+// page writes the output through the pager if it is longer than the
+// terminal is high. Otherwise, or if the pager can't be started, it is
+// written to stdout directly.
+func (o output) page(content []byte, platform string) error {
+	_, height, ok := tldr.TerminalSize(os.Stdout)
+	if !ok {
+		height = 0
+	}
+	return o.pageTo(os.Stdout, height, runPager, content, platform)
+}
+
+// pageTo writes the output through the pager to a terminal of the given
+// height, or directly to it, see page.
+func (o output) pageTo(terminal io.Writer, height int, pager func([]byte) error, content []byte, platform string) error {
+	if height > 0 {
+		// The output is decided for the terminal, not the buffer, so colors
+		// survive the pager.
+		buffered := o
+		buffered.render = o.render.Resolve(terminal)
+		var rendered bytes.Buffer
+		err := buffered.writeFormat(&rendered, bytes.NewReader(content), platform)
+		if err == nil && shouldPage(rendered.Bytes(), height) && pager(rendered.Bytes()) == nil {
+			return nil
+		}
+	}
+	// Rendering once more keeps hyperlinks, which pagers can't be relied on
+	// to pass on.
+	return o.writeFormat(terminal, bytes.NewReader(content), platform)
+}
+
+// shouldPage reports whether the output doesn't fit on a terminal of the
+// given height, leaving a line for the prompt.
+func shouldPage(output []byte, height int) bool {
+	return bytes.Count(output, []byte("\n")) >= height
+}
+
+// runPager shows the output in the pager, it only fails if the pager can't
+// be started.
+func runPager(output []byte) error {
+	args := pagerCommand()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(output)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if os.Getenv("LESS") == "" {
+		// Pass colors on if less is the pager, like with "less -R".
+		cmd.Env = append(os.Environ(), "LESS=R")
+	}
+
+	// The pager handles interrupts, tldr has to wait for it to finish.
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	_ = cmd.Wait()
+	return nil
+}
+
+// pagerCommand returns the command line of $PAGER, or of less if it isn't
+// set.
+func pagerCommand() []string {
+	if args := strings.Fields(os.Getenv("PAGER")); len(args) > 0 {
+		return args
+	}
+	return strings.Fields(defaultPager)
+}
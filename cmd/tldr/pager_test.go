@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mstruebing/tldr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagerCommand(t *testing.T) {
+	t.Setenv("PAGER", "")
+	require.Equal(t, []string{"less", "-R"}, pagerCommand())
+
+	t.Setenv("PAGER", "most -s")
+	require.Equal(t, []string{"most", "-s"}, pagerCommand())
+}
+
+func TestShouldPage(t *testing.T) {
+	output := []byte("one\ntwo\nthree\n")
+	require.True(t, shouldPage(output, 2))
+	require.True(t, shouldPage(output, 3))
+	require.False(t, shouldPage(output, 4))
+	require.False(t, shouldPage(nil, 1))
+}
+
+func TestPageTo(t *testing.T) {
+	render := tldr.Options{Color: tldr.ColorNever, Width: -1}
+	direct, err := tldr.RenderWithOptions(strings.NewReader(tarPage), render)
+	require.NoError(t, err, "RenderWithOptions() error %v", err)
+	lines := strings.Count(direct, "\n")
+
+	tests := []struct {
+		name     string
+		height   int
+		pagerErr error
+		paged    bool
+	}{
+		{name: "long page", height: lines, paged: true},
+		{name: "short page", height: lines + 1, paged: false},
+		{name: "no terminal", height: 0, paged: false},
+		{name: "pager fails", height: lines, pagerErr: errors.New("not found"), paged: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var terminal, pagerInput bytes.Buffer
+			pager := func(output []byte) error {
+				pagerInput.Write(output)
+				return tt.pagerErr
+			}
+
+			o := output{format: formatText, render: render}
+			require.NoError(t, o.pageTo(&terminal, tt.height, pager, []byte(tarPage), "common"))
+			if tt.paged {
+				require.Equal(t, direct, pagerInput.String())
+				require.Empty(t, terminal.String())
+			} else {
+				require.Equal(t, direct, terminal.String())
+			}
+		})
+	}
+}
+
+func TestPageToResolvesOptions(t *testing.T) {
+	t.Setenv("FORCE_COLOR", "")
+	t.Setenv("NO_COLOR", "")
+	// The null device is taken for a terminal, unlike the buffer the output
+	// is rendered to for the pager.
+	terminal, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	defer terminal.Close()
+
+	var pagerInput bytes.Buffer
+	pager := func(output []byte) error {
+		pagerInput.Write(output)
+		return nil
+	}
+	o := output{format: formatText, render: tldr.Options{Color: tldr.ColorAuto, Width: -1}}
+	require.NoError(t, o.pageTo(terminal, 1, pager, []byte(tarPage), "common"))
+	require.Contains(t, pagerInput.String(), "\x1b[", "expected the colors decided for the terminal")
+}
+
+func TestRunPagerFails(t *testing.T) {
+	t.Setenv("PAGER", "/nonexistent/pager")
+	require.Error(t, runPager([]byte("output\n")))
+}
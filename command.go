@@ -0,0 +1,112 @@
+package tldr
+
+import "strings"
+
+// TokenKind tells how a part of an example command should be treated.
+type TokenKind int
+
+// Token kinds
+const (
+	// TokenText is literal command text.
+	TokenText TokenKind = iota
+	// TokenPlaceholder is a value the user has to fill in.
+	TokenPlaceholder
+	// TokenOption is an option given in its short and long form, written
+	// as {{[-o|--option]}} in the page.
+	TokenOption
+)
+
+// Token is a part of an example command. Short and Long are only set for
+// TokenOption, whose Text holds both forms.
+type Token struct {
+	Kind  TokenKind
+	Text  string
+	Short string
+	Long  string
+}
+
+// OptionForm selects how options given in both forms are displayed.
+type OptionForm int
+
+// Option forms
+const (
+	// OptionFormBoth displays both forms as [-o|--option].
+	OptionFormBoth OptionForm = iota
+	// OptionFormShort displays the short form only.
+	OptionFormShort
+	// OptionFormLong displays the long form only.
+	OptionFormLong
+)
+
+// Display returns the text of the token, with options in the given form.
+func (t Token) Display(form OptionForm) string {
+	if t.Kind == TokenOption {
+		switch form {
+		case OptionFormShort:
+			return t.Short
+		case OptionFormLong:
+			return t.Long
+		}
+	}
+	return t.Text
+}
+
+// tokenize splits a command into literal text, placeholders and options.
+// Literal braces are escaped by doubling them, so {{{{ and }}}} stand for
+// {{ and }}.
+func tokenize(command string) []Token {
+	tokens := []Token{}
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			tokens = append(tokens, Token{Kind: TokenText, Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(command); {
+		rest := command[i:]
+		switch {
+		case strings.HasPrefix(rest, "{{{{"):
+			text.WriteString("{{")
+			i += 4
+		case strings.HasPrefix(rest, "}}}}"):
+			text.WriteString("}}")
+			i += 4
+		case strings.HasPrefix(rest, "{{"):
+			end := strings.Index(rest[2:], "}}")
+			if end == -1 {
+				// Unclosed placeholder, keep it as it is.
+				text.WriteString(rest)
+				i = len(command)
+				continue
+			}
+			end += 2
+			// Braces right before the closing ones belong to the placeholder.
+			for end+2 < len(rest) && rest[end+2] == '}' {
+				end++
+			}
+
+			flush()
+			tokens = append(tokens, placeholder(rest[2:end]))
+			i += end + 2
+		default:
+			text.WriteByte(command[i])
+			i++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// placeholder returns the token for the content of a {{placeholder}}, which
+// is an option if it has the form [-o|--option].
+func placeholder(content string) Token {
+	if strings.HasPrefix(content, "[") && strings.HasSuffix(content, "]") {
+		forms := strings.Split(content[1:len(content)-1], "|")
+		if len(forms) == 2 && strings.HasPrefix(forms[0], "-") && strings.HasPrefix(forms[1], "-") {
+			return Token{Kind: TokenOption, Text: content, Short: forms[0], Long: forms[1]}
+		}
+	}
+	return Token{Kind: TokenPlaceholder, Text: content}
+}
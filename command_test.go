@@ -0,0 +1,79 @@
+package tldr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []Token
+	}{
+		{
+			name:    "no placeholders",
+			command: "ls -la",
+			want:    []Token{{Kind: TokenText, Text: "ls -la"}},
+		},
+		{
+			name:    "adjacent placeholders",
+			command: "{{a}}{{b}}",
+			want:    []Token{{Kind: TokenPlaceholder, Text: "a"}, {Kind: TokenPlaceholder, Text: "b"}},
+		},
+		{
+			name:    "unclosed placeholder",
+			command: "echo {{a",
+			want:    []Token{{Kind: TokenText, Text: "echo {{a"}},
+		},
+		{
+			name:    "escaped braces",
+			command: "echo '{{{{ .Name }}}}' {{file}}",
+			want: []Token{
+				{Kind: TokenText, Text: "echo '{{ .Name }}' "},
+				{Kind: TokenPlaceholder, Text: "file"},
+			},
+		},
+		{
+			name:    "braces inside placeholder",
+			command: "jq {{{.key}}}",
+			want:    []Token{{Kind: TokenText, Text: "jq "}, {Kind: TokenPlaceholder, Text: "{.key}"}},
+		},
+		{
+			name:    "option",
+			command: "tar {{[-x|--extract]}} {{[-f|--file]}} {{path/to/file}}",
+			want: []Token{
+				{Kind: TokenText, Text: "tar "},
+				{Kind: TokenOption, Text: "[-x|--extract]", Short: "-x", Long: "--extract"},
+				{Kind: TokenText, Text: " "},
+				{Kind: TokenOption, Text: "[-f|--file]", Short: "-f", Long: "--file"},
+				{Kind: TokenText, Text: " "},
+				{Kind: TokenPlaceholder, Text: "path/to/file"},
+			},
+		},
+		{
+			name:    "brackets without options",
+			command: "echo {{[a|b]}}",
+			want:    []Token{{Kind: TokenText, Text: "echo "}, {Kind: TokenPlaceholder, Text: "[a|b]"}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, tokenize(tt.command))
+		})
+	}
+}
+
+func TestTokenDisplay(t *testing.T) {
+	option := Token{Kind: TokenOption, Text: "[-x|--extract]", Short: "-x", Long: "--extract"}
+	require.Equal(t, "[-x|--extract]", option.Display(OptionFormBoth))
+	require.Equal(t, "-x", option.Display(OptionFormShort))
+	require.Equal(t, "--extract", option.Display(OptionFormLong))
+
+	placeholder := Token{Kind: TokenPlaceholder, Text: "file"}
+	require.Equal(t, "file", placeholder.Display(OptionFormShort))
+}
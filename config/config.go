@@ -0,0 +1,107 @@
+// Package config loads the user configuration of tldr.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/mstruebing/tldr"
+)
+
+const (
+	configFile   = "config.json"
+	defaultTheme = "default"
+)
+
+// Cache forms
+const (
+	// CacheExtracted extracts the pages archive into the cache directory.
+	CacheExtracted = "extracted"
+	// CacheZip keeps the pages archive and reads the pages from it.
+	CacheZip = "zip"
+)
+
+// Config is the user configuration read from the config file.
+type Config struct {
+	// Theme is the name of the selected theme, either a built-in one or one
+	// defined in Themes.
+	Theme string `json:"theme"`
+	// Themes defines custom themes by name.
+	Themes map[string]tldr.Theme `json:"themes"`
+	// Pager disables paging long pages if set to false.
+	Pager *bool `json:"pager"`
+	// ChecksumURL is the location of the SHA-256 checksum of the pages
+	// archive, see cache.Options.
+	ChecksumURL string `json:"checksum_url"`
+	// Cache selects how the pages are kept in the cache, CacheExtracted if
+	// empty.
+	Cache string `json:"cache"`
+}
+
+// PagerEnabled reports whether long pages should be shown in a pager.
+func (c *Config) PagerEnabled() bool {
+	return c.Pager == nil || *c.Pager
+}
+
+// Load reads the config file. The default configuration is returned if there
+// is no config file.
+func Load() (*Config, error) {
+	file, err := File()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("ERROR: reading config file: %s", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("ERROR: parsing config file '%s': %s", file, err)
+	}
+	return &config, nil
+}
+
+// File returns the path of the config file.
+func File() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(dir, configFile), nil
+}
+
+// LookupTheme returns the theme with the given name, or the configured one if
+// the name is empty. Themes of the config file take precedence over the
+// built-in themes.
+func (c *Config) LookupTheme(name string) (tldr.Theme, error) {
+	if name == "" {
+		name = c.Theme
+	}
+	if name == "" {
+		name = defaultTheme
+	}
+
+	if theme, ok := c.Themes[name]; ok {
+		return theme, nil
+	}
+	return tldr.BuiltinTheme(name)
+}
+
+func configDir() (string, error) {
+	// Use the XDG_CONFIG_HOME environment variable if possible
+	if XDG_CONFIG_HOME := os.Getenv("XDG_CONFIG_HOME"); XDG_CONFIG_HOME != "" {
+		return path.Join(XDG_CONFIG_HOME, "tldr"), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("ERROR: getting home directory: %s", err)
+	}
+	return path.Join(homeDir, ".config", "tldr"), nil
+}
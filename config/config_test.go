@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/mstruebing/tldr"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	require.NoError(t, os.MkdirAll(path.Join(dir, "tldr"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(dir, "tldr", configFile), []byte(content), 0644))
+}
+
+func TestFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp")
+	file, err := File()
+	require.NoError(t, err, "File() error %v", err)
+	require.Equal(t, "/tmp/tldr/config.json", file)
+}
+
+func TestLoadMissing(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	config, err := Load()
+	require.NoError(t, err, "Load() error %v", err)
+
+	theme, err := config.LookupTheme("")
+	require.NoError(t, err, "LookupTheme() error %v", err)
+	require.Equal(t, tldr.DefaultTheme, theme)
+}
+
+func TestLoadTheme(t *testing.T) {
+	writeConfig(t, `{
+		"theme": "mine",
+		"themes": {
+			"mine": {
+				"title": {"foreground": "bright-cyan", "bold": true},
+				"placeholder": {"foreground": "214", "background": "#102030", "underline": true}
+			}
+		}
+	}`)
+
+	config, err := Load()
+	require.NoError(t, err, "Load() error %v", err)
+
+	theme, err := config.LookupTheme("")
+	require.NoError(t, err, "LookupTheme() error %v", err)
+	require.Equal(t, tldr.Style{Foreground: "bright-cyan", Bold: true}, theme.Title)
+	require.Equal(t, tldr.Style{Foreground: "214", Background: "#102030", Underline: true}, theme.Placeholder)
+
+	theme, err = config.LookupTheme("light")
+	require.NoError(t, err, "LookupTheme() error %v", err)
+	light, _ := tldr.BuiltinTheme("light")
+	require.Equal(t, light, theme)
+
+	_, err = config.LookupTheme("unknown")
+	require.Error(t, err, "expected an error for an unknown theme")
+}
+
+func TestLoadPager(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	config, err := Load()
+	require.NoError(t, err, "Load() error %v", err)
+	require.True(t, config.PagerEnabled())
+
+	writeConfig(t, `{"pager": false}`)
+	config, err = Load()
+	require.NoError(t, err, "Load() error %v", err)
+	require.False(t, config.PagerEnabled())
+}
+
+func TestLoadInvalidColor(t *testing.T) {
+	writeConfig(t, `{"themes": {"mine": {"title": {"foreground": "purple"}}}}`)
+
+	_, err := Load()
+	require.Error(t, err, "expected an error for an invalid color")
+}
+
+func TestLoadCache(t *testing.T) {
+	writeConfig(t, `{"cache": "zip"}`)
+	config, err := Load()
+	require.NoError(t, err, "Load() error %v", err)
+	require.Equal(t, CacheZip, config.Cache)
+}
@@ -0,0 +1,77 @@
+package tldr
+
+import (
+	"io"
+	"strings"
+)
+
+var braceEscaper = strings.NewReplacer("{{", "{{{{", "}}", "}}}}")
+
+// Markdown prints the page in the canonical markdown form of the tldr-pages
+// style. Parsing the result yields the same page again.
+func (p *Page) Markdown() string {
+	var markdown strings.Builder
+	markdown.WriteString("# " + p.Name + "\n")
+
+	if len(p.Description) > 0 || p.MoreInfoURL != "" {
+		markdown.WriteString("\n")
+	}
+	for _, line := range p.Description {
+		markdown.WriteString("> " + line + "\n")
+	}
+	if p.MoreInfoURL != "" {
+		markdown.WriteString("> " + moreInfoPrefix + " <" + p.MoreInfoURL + ">.\n")
+	}
+
+	for _, example := range p.Examples {
+		markdown.WriteString("\n- " + example.Description + "\n\n`" + example.CommandMarkdown() + "`\n")
+	}
+	return markdown.String()
+}
+
+// CommandMarkdown returns the command of the example as written in the page,
+// with the placeholders in braces.
+func (e Example) CommandMarkdown() string {
+	var command strings.Builder
+	for _, token := range e.Command {
+		if token.Kind == TokenText {
+			command.WriteString(braceEscaper.Replace(token.Text))
+		} else {
+			command.WriteString("{{" + token.Text + "}}")
+		}
+	}
+	return command.String()
+}
+
+// Normalize fixes the style of the page: example descriptions end with a
+// colon and placeholders have no surrounding spaces.
+func (p *Page) Normalize() {
+	for i := range p.Examples {
+		example := &p.Examples[i]
+		if description := strings.TrimRight(example.Description, ".;, "); description != "" && !strings.HasSuffix(description, ":") {
+			example.Description = description + ":"
+		}
+
+		for j := range example.Command {
+			token := &example.Command[j]
+			if token.Kind != TokenText {
+				*token = placeholder(strings.TrimSpace(token.Text))
+			}
+		}
+	}
+}
+
+// Format parses the markdown and returns the normalized page in canonical
+// form. Malformed lines are returned as *ParseError, as the page couldn't be
+// printed without losing them.
+func Format(markdown io.Reader) (string, error) {
+	page, err := Parse(markdown)
+	if err != nil {
+		return "", err
+	}
+	if len(page.Warnings) > 0 {
+		return "", page.Warnings[0]
+	}
+	page.Normalize()
+	return page.Markdown(), nil
+}
@@ -0,0 +1,70 @@
+package tldr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdown(t *testing.T) {
+	page, err := Parse(strings.NewReader(catPage))
+	require.NoError(t, err, "Parse() error %v", err)
+	require.Equal(t, catPage, page.Markdown())
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	markdown := "# jq\n\n> JSON processor.\n> More information: <https://jqlang.github.io/jq>.\n\n" +
+		"- Print a template:\n\n`echo '{{{{ .Name }}}}' {{[-o|--output]}} {{{.key}}} `date``\n"
+
+	page, err := Parse(strings.NewReader(markdown))
+	require.NoError(t, err, "Parse() error %v", err)
+	require.Equal(t, markdown, page.Markdown())
+
+	reparsed, err := Parse(strings.NewReader(page.Markdown()))
+	require.NoError(t, err, "Parse() error %v", err)
+	require.Equal(t, page, reparsed)
+}
+
+func TestFormat(t *testing.T) {
+	markdown := "#  cat  \n> Print and concatenate files.   \n>More information: <https://www.gnu.org/software/coreutils/cat>.\n\n\n" +
+		"-  Print the contents of a file to `stdout`\ncat {{ path/to/file }}\n" +
+		"- Concatenate several files into an output file.\n\n`cat {{path/to/file1 path/to/file2 ...}} > {{path/to/output_file}}`\n\n\n"
+
+	formatted, err := Format(strings.NewReader(markdown))
+	require.NoError(t, err, "Format() error %v", err)
+	require.Equal(t, catPage, formatted)
+
+	again, err := Format(strings.NewReader(formatted))
+	require.NoError(t, err, "Format() error %v", err)
+	require.Equal(t, formatted, again)
+}
+
+func TestFormatMalformed(t *testing.T) {
+	_, err := Format(strings.NewReader("# cat\n\nsome text\n"))
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	require.Equal(t, ParseError{Line: 3, Msg: "unexpected text 'some text' outside of an example"}, *parseErr)
+}
+
+func TestNormalizeOptions(t *testing.T) {
+	page := &Page{Examples: []Example{{Description: "Extract", Command: tokenize("tar {{ [-x|--extract] }}")}}}
+	page.Normalize()
+	require.Equal(t, "Extract:", page.Examples[0].Description)
+	require.Equal(t, Token{Kind: TokenOption, Text: "[-x|--extract]", Short: "-x", Long: "--extract"}, page.Examples[0].Command[1])
+}
+
+func FuzzMarkdown(f *testing.F) {
+	f.Add(catPage)
+	f.Add("# x\n- a\n``{{{{a}}}}`\n- b\n`{{{b}}}}}} }}}`\n")
+	f.Fuzz(func(t *testing.T, markdown string) {
+		page, err := Parse(strings.NewReader(markdown))
+		// Malformed lines are skipped, so the page doesn't round trip.
+		if err != nil || len(page.Warnings) > 0 {
+			return
+		}
+		reparsed, err := Parse(strings.NewReader(page.Markdown()))
+		require.NoError(t, err, "Parse() error %v for %q", err, page.Markdown())
+		require.Equal(t, page, reparsed, "round trip of %q", page.Markdown())
+	})
+}
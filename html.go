@@ -0,0 +1,74 @@
+package tldr
+
+import (
+	"html"
+	"strings"
+)
+
+// RenderHTML renders the page as HTML fragment. Placeholders are marked up
+// as <var> elements and options as <span class="option">, so they can be
+// styled. Code in descriptions referring to another page is linked to the
+// location returned by link, which may be nil if pages shouldn't be linked.
+func RenderHTML(page *Page, form OptionForm, link func(page string) string) string {
+	var rendered strings.Builder
+	rendered.WriteString("<article class=\"tldr-page\">\n")
+	rendered.WriteString("<h1>" + html.EscapeString(page.Name) + "</h1>\n")
+	for _, line := range page.Description {
+		rendered.WriteString("<p class=\"description\">" + htmlText(line, link) + "</p>\n")
+	}
+	if page.MoreInfoURL != "" {
+		url := html.EscapeString(page.MoreInfoURL)
+		rendered.WriteString("<p class=\"more-info\">" + moreInfoPrefix + " <a href=\"" + url + "\">" + url + "</a>.</p>\n")
+	}
+
+	if len(page.Examples) > 0 {
+		rendered.WriteString("<ul class=\"examples\">\n")
+	}
+	for _, example := range page.Examples {
+		rendered.WriteString("<li class=\"example\">\n")
+		rendered.WriteString("<p class=\"example-description\">" + htmlText(example.Description, link) + "</p>\n")
+		rendered.WriteString("<pre><code class=\"command\">")
+		for _, token := range example.Command {
+			text := html.EscapeString(token.Display(form))
+			switch token.Kind {
+			case TokenPlaceholder:
+				rendered.WriteString("<var class=\"placeholder\">" + text + "</var>")
+			case TokenOption:
+				rendered.WriteString("<span class=\"option\">" + text + "</span>")
+			default:
+				rendered.WriteString(text)
+			}
+		}
+		rendered.WriteString("</code></pre>\n</li>\n")
+	}
+	if len(page.Examples) > 0 {
+		rendered.WriteString("</ul>\n")
+	}
+	rendered.WriteString("</article>\n")
+	return rendered.String()
+}
+
+// htmlText escapes the text of a description and marks up `code`, which is
+// linked if it names a page.
+func htmlText(text string, link func(page string) string) string {
+	parts := strings.Split(text, "`")
+	var rendered strings.Builder
+	for i, part := range parts {
+		switch {
+		case i%2 == 0:
+			rendered.WriteString(html.EscapeString(part))
+		case i == len(parts)-1:
+			// Unbalanced backtick
+			rendered.WriteString("`" + html.EscapeString(part))
+		default:
+			code := "<code>" + html.EscapeString(part) + "</code>"
+			if link != nil {
+				if href := link(strings.ReplaceAll(strings.ToLower(part), " ", "-")); href != "" {
+					code = "<a href=\"" + html.EscapeString(href) + "\">" + code + "</a>"
+				}
+			}
+			rendered.WriteString(code)
+		}
+	}
+	return rendered.String()
+}
@@ -0,0 +1,48 @@
+package tldr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderHTML(t *testing.T) {
+	page, err := Parse(strings.NewReader(catPage))
+	require.NoError(t, err, "Parse() error %v", err)
+
+	link := func(name string) string {
+		if name == "stdout" {
+			return "../common/stdout.html"
+		}
+		return ""
+	}
+
+	require.Equal(t, `<article class="tldr-page">
+<h1>cat</h1>
+<p class="description">Print and concatenate files.</p>
+<p class="more-info">More information: <a href="https://www.gnu.org/software/coreutils/cat">https://www.gnu.org/software/coreutils/cat</a>.</p>
+<ul class="examples">
+<li class="example">
+<p class="example-description">Print the contents of a file to <a href="../common/stdout.html"><code>stdout</code></a>:</p>
+<pre><code class="command">cat <var class="placeholder">path/to/file</var></code></pre>
+</li>
+<li class="example">
+<p class="example-description">Concatenate several files into an output file:</p>
+<pre><code class="command">cat <var class="placeholder">path/to/file1 path/to/file2 ...</var> &gt; <var class="placeholder">path/to/output_file</var></code></pre>
+</li>
+</ul>
+</article>
+`, RenderHTML(page, OptionFormBoth, link))
+}
+
+func TestRenderHTMLOptions(t *testing.T) {
+	page := &Page{
+		Name:     "tar",
+		Examples: []Example{{Description: "Extract <archive>:", Command: tokenize("tar {{[-x|--extract]}}")}},
+	}
+
+	rendered := RenderHTML(page, OptionFormLong, nil)
+	require.Contains(t, rendered, `<p class="example-description">Extract &lt;archive&gt;:</p>`)
+	require.Contains(t, rendered, `<code class="command">tar <span class="option">--extract</span></code>`)
+}
@@ -0,0 +1,42 @@
+package tldr
+
+import (
+	"os"
+	"strconv"
+)
+
+// hyperlink wraps the text in an OSC 8 escape sequence linking it to the
+// url.
+func hyperlink(url, text string) string {
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// supportsHyperlinks guesses from the environment whether the terminal
+// supports OSC 8 hyperlinks. FORCE_HYPERLINK overrides the detection.
+func supportsHyperlinks() bool {
+	if force := os.Getenv("FORCE_HYPERLINK"); force != "" {
+		return force != "0" && force != "false"
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper":
+		return true
+	}
+	switch os.Getenv("TERM") {
+	case "xterm-kitty", "alacritty", "foot", "xterm-ghostty", "wezterm":
+		return true
+	}
+	if os.Getenv("WT_SESSION") != "" || os.Getenv("KITTY_WINDOW_ID") != "" {
+		return true
+	}
+	if version, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && version >= 5000 {
+		return true
+	}
+	if version, err := strconv.Atoi(os.Getenv("KONSOLE_VERSION")); err == nil && version >= 201200 {
+		return true
+	}
+	return false
+}
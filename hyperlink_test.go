@@ -0,0 +1,44 @@
+package tldr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupportsHyperlinks(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want bool
+	}{
+		{name: "unknown terminal", env: map[string]string{"TERM": "xterm-256color"}, want: false},
+		{name: "dumb terminal", env: map[string]string{"TERM": "dumb", "TERM_PROGRAM": "vscode"}, want: false},
+		{name: "iterm", env: map[string]string{"TERM_PROGRAM": "iTerm.app"}, want: true},
+		{name: "kitty", env: map[string]string{"TERM": "xterm-kitty"}, want: true},
+		{name: "old vte", env: map[string]string{"VTE_VERSION": "4601"}, want: false},
+		{name: "new vte", env: map[string]string{"VTE_VERSION": "6003"}, want: true},
+		{name: "windows terminal", env: map[string]string{"WT_SESSION": "1"}, want: true},
+		{name: "forced", env: map[string]string{"FORCE_HYPERLINK": "1", "TERM": "dumb"}, want: true},
+		{name: "forced off", env: map[string]string{"FORCE_HYPERLINK": "0", "TERM_PROGRAM": "vscode"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"FORCE_HYPERLINK", "TERM", "TERM_PROGRAM", "WT_SESSION", "KITTY_WINDOW_ID", "VTE_VERSION", "KONSOLE_VERSION"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			require.Equal(t, tt.want, supportsHyperlinks())
+		})
+	}
+}
+
+func TestRenderHyperlink(t *testing.T) {
+	moreInfo := element{kind: elementMoreInfo, text: "https://www.gnu.org/software/coreutils/cat"}
+
+	p := &pageWriter{color: true, hyperlinks: true}
+	require.Equal(t, "More information: \x1b]8;;https://www.gnu.org/software/coreutils/cat\x1b\\https://www.gnu.org/software/coreutils/cat\x1b]8;;\x1b\\.\n", p.render(moreInfo))
+
+	p = &pageWriter{color: true, hyperlinks: false}
+	require.Equal(t, "More information: <https://www.gnu.org/software/coreutils/cat>.\n", p.render(moreInfo))
+}
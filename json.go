@@ -0,0 +1,86 @@
+package tldr
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// DefaultLanguage is the language of the pages in the default archive.
+const DefaultLanguage = "en"
+
+// JSONPage is the JSON document written by WriteJSON.
+type JSONPage struct {
+	Name        string        `json:"name"`
+	Platform    string        `json:"platform"`
+	Language    string        `json:"language"`
+	Description string        `json:"description"`
+	MoreInfoURL string        `json:"more_info_url"`
+	Examples    []JSONExample `json:"examples"`
+}
+
+// JSONExample is an example of a JSONPage. The command is given without the
+// placeholder markers, the placeholders and options are marked by their spans
+// instead.
+type JSONExample struct {
+	Description  string `json:"description"`
+	Command      string `json:"command"`
+	Placeholders []Span `json:"placeholders"`
+	Options      []Span `json:"options"`
+}
+
+// Span marks a part of a command by its start and end byte offsets.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// NewJSONPage converts the page into its JSON document, with options in the
+// given form.
+func NewJSONPage(page *Page, platform, language string, form OptionForm) JSONPage {
+	doc := JSONPage{
+		Name:        page.Name,
+		Platform:    platform,
+		Language:    language,
+		Description: strings.Join(page.Description, "\n"),
+		MoreInfoURL: page.MoreInfoURL,
+		Examples:    make([]JSONExample, len(page.Examples)),
+	}
+
+	for i, example := range page.Examples {
+		var command strings.Builder
+		placeholders, options := []Span{}, []Span{}
+		for _, token := range example.Command {
+			text := token.Display(form)
+			span := Span{Start: command.Len(), End: command.Len() + len(text)}
+			switch token.Kind {
+			case TokenPlaceholder:
+				placeholders = append(placeholders, span)
+			case TokenOption:
+				options = append(options, span)
+			}
+			command.WriteString(text)
+		}
+		doc.Examples[i] = JSONExample{
+			Description:  example.Description,
+			Command:      command.String(),
+			Placeholders: placeholders,
+			Options:      options,
+		}
+	}
+	return doc
+}
+
+// WriteJSON parses the markdown and writes the page as JSON document to the
+// destination, with options in the given form.
+func WriteJSON(markdown io.Reader, dest io.Writer, platform, language string, form OptionForm) error {
+	page, err := Parse(markdown)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(dest)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(NewJSONPage(page, platform, language, form))
+}
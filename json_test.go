@@ -0,0 +1,59 @@
+package tldr
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSON(t *testing.T) {
+	var out bytes.Buffer
+	err := WriteJSON(strings.NewReader(catPage), &out, "common", DefaultLanguage, OptionFormBoth)
+	require.NoError(t, err, "WriteJSON() error %v", err)
+
+	var doc JSONPage
+	require.NoError(t, json.Unmarshal(out.Bytes(), &doc))
+
+	require.Equal(t, "cat", doc.Name)
+	require.Equal(t, "common", doc.Platform)
+	require.Equal(t, "en", doc.Language)
+	require.Equal(t, "Print and concatenate files.", doc.Description)
+	require.Equal(t, "https://www.gnu.org/software/coreutils/cat", doc.MoreInfoURL)
+	require.Len(t, doc.Examples, 2)
+
+	example := doc.Examples[1]
+	require.Equal(t, "cat path/to/file1 path/to/file2 ... > path/to/output_file", example.Command)
+	require.Equal(t, []Span{{Start: 4, End: 35}, {Start: 38, End: 57}}, example.Placeholders)
+	require.Equal(t, "path/to/output_file", example.Command[example.Placeholders[1].Start:example.Placeholders[1].End])
+	require.Empty(t, example.Options)
+}
+
+func TestWriteJSONOptions(t *testing.T) {
+	markdown := "# tar\n\n> Archiving utility.\n\n- Extract an archive:\n\n`tar {{[-x|--extract]}} {{[-f|--file]}} {{path/to/file.tar}}`\n"
+
+	tests := []struct {
+		form    OptionForm
+		command string
+		options []Span
+	}{
+		{form: OptionFormBoth, command: "tar [-x|--extract] [-f|--file] path/to/file.tar", options: []Span{{Start: 4, End: 18}, {Start: 19, End: 30}}},
+		{form: OptionFormShort, command: "tar -x -f path/to/file.tar", options: []Span{{Start: 4, End: 6}, {Start: 7, End: 9}}},
+		{form: OptionFormLong, command: "tar --extract --file path/to/file.tar", options: []Span{{Start: 4, End: 13}, {Start: 14, End: 20}}},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		err := WriteJSON(strings.NewReader(markdown), &out, "common", DefaultLanguage, tt.form)
+		require.NoError(t, err, "WriteJSON() error %v", err)
+
+		var doc JSONPage
+		require.NoError(t, json.Unmarshal(out.Bytes(), &doc))
+		example := doc.Examples[0]
+		require.Equal(t, tt.command, example.Command)
+		require.Equal(t, tt.options, example.Options)
+		require.Equal(t, "path/to/file.tar", example.Command[example.Placeholders[0].Start:example.Placeholders[0].End])
+	}
+}
@@ -0,0 +1,324 @@
+package tldr
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Lint rules
+const (
+	RuleTitle              = "title"
+	RuleDescription        = "description"
+	RuleMoreInfo           = "more-info"
+	RuleExampleDescription = "example-description"
+	RuleCommand            = "command"
+	RulePlaceholder        = "placeholder"
+	RuleBlankLine          = "blank-line"
+	RuleTrailingWhitespace = "trailing-whitespace"
+	RuleLineEnding         = "line-ending"
+	RuleStructure          = "structure"
+)
+
+// Diagnostic is a violation of the tldr-pages style rules at a position of
+// the page. Lines and columns start at 1, the column counts characters.
+type Diagnostic struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Column, d.Rule, d.Message)
+}
+
+type lineKind int
+
+const (
+	lineBlank lineKind = iota
+	lineTitle
+	lineDescription
+	lineExample
+	lineCommand
+	lineText
+)
+
+// Lint checks the markdown of a page against the tldr-pages style rules and
+// returns the violations ordered by their position.
+func Lint(markdown io.Reader) ([]Diagnostic, error) {
+	content, err := io.ReadAll(markdown)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &linter{diagnostics: []Diagnostic{}}
+	l.lint(string(content))
+	return l.diagnostics, nil
+}
+
+type linter struct {
+	diagnostics []Diagnostic
+}
+
+func (l *linter) report(line, column int, rule, format string, args ...interface{}) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{Line: line, Column: column, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) lint(content string) {
+	if content == "" {
+		l.report(1, 1, RuleTitle, "page is empty")
+		return
+	}
+
+	lines := strings.Split(content, "\n")
+	if last := lines[len(lines)-1]; last != "" {
+		l.report(len(lines), utf8.RuneCountInString(last)+1, RuleBlankLine, "file must end with a newline")
+	} else {
+		lines = lines[:len(lines)-1]
+	}
+
+	kinds := make([]lineKind, len(lines))
+	for i, line := range lines {
+		if strings.HasSuffix(line, "\r") {
+			l.report(i+1, utf8.RuneCountInString(line), RuleLineEnding, "line must end with LF, not CRLF")
+			line = strings.TrimSuffix(line, "\r")
+			lines[i] = line
+		}
+		if trimmed := strings.TrimRight(line, " \t"); trimmed != line {
+			l.report(i+1, utf8.RuneCountInString(trimmed)+1, RuleTrailingWhitespace, "line has trailing whitespace")
+		}
+		kinds[i] = classify(line)
+	}
+
+	l.lintStructure(lines, kinds)
+	for i, line := range lines {
+		number := i + 1
+		switch kinds[i] {
+		case lineTitle:
+			l.lintTitle(number, line)
+		case lineDescription:
+			l.lintDescription(number, line)
+		case lineExample:
+			l.lintExampleDescription(number, line)
+		case lineCommand:
+			l.lintCommand(number, line)
+		}
+	}
+	sortDiagnostics(l.diagnostics)
+}
+
+func classify(line string) lineKind {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "":
+		return lineBlank
+	case strings.HasPrefix(trimmed, "#"):
+		return lineTitle
+	case strings.HasPrefix(trimmed, ">"):
+		return lineDescription
+	case strings.HasPrefix(trimmed, "-"):
+		return lineExample
+	case strings.HasPrefix(trimmed, "`"):
+		return lineCommand
+	}
+	return lineText
+}
+
+// lintStructure checks the order of the lines and the blank lines between
+// them: a title, a blank line, the description, and examples made of their
+// description, a blank line and the command, separated by blank lines.
+func (l *linter) lintStructure(lines []string, kinds []lineKind) {
+	if kinds[0] != lineTitle {
+		l.report(1, 1, RuleTitle, "page must start with a title")
+	}
+
+	var titles, moreInfos int
+	var seenDescription, seenExample bool
+	lastDescription := 0
+	for i, kind := range kinds {
+		number := i + 1
+		previous, next := lineBlank, lineBlank
+		if i > 0 {
+			previous = kinds[i-1]
+		}
+		if i+1 < len(kinds) {
+			next = kinds[i+1]
+		}
+
+		switch kind {
+		case lineBlank:
+			if i == 0 || previous == lineBlank {
+				l.report(number, 1, RuleBlankLine, "unexpected blank line")
+			} else if i == len(kinds)-1 {
+				l.report(number, 1, RuleBlankLine, "page must not end with a blank line")
+			} else if previous == lineDescription && next == lineDescription {
+				l.report(number, 1, RuleBlankLine, "description must not be interrupted by blank lines")
+			}
+		case lineTitle:
+			titles++
+			if titles > 1 {
+				l.report(number, 1, RuleTitle, "page must have only one title")
+			}
+			if i+1 < len(kinds) && next != lineBlank {
+				l.report(number+1, 1, RuleBlankLine, "title must be followed by a blank line")
+			}
+		case lineDescription:
+			seenDescription = true
+			lastDescription = number
+			if seenExample {
+				l.report(number, 1, RuleStructure, "description must come before the examples")
+			}
+			if strings.HasPrefix(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")), moreInfoPrefix) {
+				moreInfos++
+			}
+			if next != lineBlank && next != lineDescription {
+				l.report(number+1, 1, RuleBlankLine, "description must be followed by a blank line")
+			}
+		case lineExample:
+			seenExample = true
+			// Text instead of the command is reported as unwrapped command.
+			if next != lineBlank || i+2 >= len(kinds) || (kinds[i+2] != lineCommand && kinds[i+2] != lineText) {
+				l.report(number, 1, RuleStructure, "example description must be followed by a blank line and the command")
+			}
+		case lineCommand:
+			if previous != lineBlank || i < 2 || kinds[i-2] != lineExample {
+				l.report(number, 1, RuleStructure, "command must follow an example description and a blank line")
+			}
+			if next != lineBlank {
+				l.report(number+1, 1, RuleBlankLine, "command must be followed by a blank line")
+			}
+		case lineText:
+			if previous == lineBlank && i >= 2 && kinds[i-2] == lineExample {
+				l.report(number, 1, RuleCommand, "command must be wrapped in backticks")
+			} else {
+				l.report(number, 1, RuleStructure, "unexpected text outside of an example")
+			}
+		}
+	}
+
+	if !seenDescription {
+		l.report(1, 1, RuleDescription, "page must have a description")
+	} else if moreInfos == 0 {
+		l.report(lastDescription, 1, RuleMoreInfo, "description must end with a \"More information\" link")
+	}
+	if !seenExample {
+		l.report(len(lines), 1, RuleStructure, "page must have examples")
+	}
+}
+
+func (l *linter) lintTitle(number int, line string) {
+	if !strings.HasPrefix(line, "# ") || strings.HasPrefix(line, "#  ") {
+		l.report(number, 1, RuleTitle, "title must start with '# ' followed by the command name")
+		return
+	}
+	name := strings.TrimSpace(line[2:])
+	if name == "" {
+		l.report(number, 3, RuleTitle, "title must not be empty")
+	} else if strings.HasSuffix(name, ".") {
+		l.report(number, utf8.RuneCountInString(strings.TrimRight(line, " \t")), RuleTitle, "title must not end with a period")
+	}
+}
+
+func (l *linter) lintDescription(number int, line string) {
+	if !strings.HasPrefix(line, "> ") || strings.HasPrefix(line, ">  ") {
+		l.report(number, 1, RuleDescription, "description must start with '> '")
+		return
+	}
+	text := strings.TrimRight(line[2:], " \t")
+	end := utf8.RuneCountInString(line[:2] + text)
+
+	if strings.HasPrefix(text, moreInfoPrefix) {
+		url := strings.TrimSpace(strings.TrimPrefix(text, moreInfoPrefix))
+		if !strings.HasPrefix(url, "<") || !strings.HasSuffix(url, ">.") || len(url) < 4 {
+			l.report(number, 3, RuleMoreInfo, "link must be written as 'More information: <url>.'")
+		}
+		return
+	}
+
+	if text == "" {
+		l.report(number, 3, RuleDescription, "description must not be empty")
+		return
+	}
+	if first, _ := utf8.DecodeRuneInString(text); unicode.IsLower(first) {
+		l.report(number, 3, RuleDescription, "description must start with an uppercase letter")
+	}
+	if !strings.HasSuffix(text, ".") {
+		l.report(number, end+1, RuleDescription, "description must end with a period")
+	}
+}
+
+func (l *linter) lintExampleDescription(number int, line string) {
+	if !strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "-  ") {
+		l.report(number, 1, RuleExampleDescription, "example description must start with '- '")
+		return
+	}
+	text := strings.TrimRight(line[2:], " \t")
+	if text == "" {
+		l.report(number, 3, RuleExampleDescription, "example description must not be empty")
+		return
+	}
+	if first, _ := utf8.DecodeRuneInString(text); unicode.IsLower(first) {
+		l.report(number, 3, RuleExampleDescription, "example description must start with an uppercase letter")
+	}
+	if !strings.HasSuffix(text, ":") {
+		l.report(number, utf8.RuneCountInString(line[:2]+text)+1, RuleExampleDescription, "example description must end with a colon")
+	}
+}
+
+func (l *linter) lintCommand(number int, line string) {
+	command := strings.TrimRight(line, " \t")
+	if len(command) < 2 || !strings.HasSuffix(command, "`") {
+		l.report(number, utf8.RuneCountInString(command)+1, RuleCommand, "command must be wrapped in backticks")
+		return
+	}
+	if strings.HasPrefix(command, "``") {
+		l.report(number, 2, RuleCommand, "command must not be empty")
+		return
+	}
+	l.lintPlaceholders(number, command)
+}
+
+// lintPlaceholders reports unbalanced placeholder braces, escaped braces
+// ({{{{ and }}}}) are skipped.
+func (l *linter) lintPlaceholders(number int, command string) {
+	open := -1
+	for i := 0; i < len(command); {
+		rest := command[i:]
+		switch {
+		case strings.HasPrefix(rest, "{{{{") || strings.HasPrefix(rest, "}}}}"):
+			i += 4
+		case strings.HasPrefix(rest, "{{"):
+			if open != -1 {
+				l.report(number, utf8.RuneCountInString(command[:i])+1, RulePlaceholder, "placeholders must not be nested")
+			}
+			open = i
+			i += 2
+		case strings.HasPrefix(rest, "}}"):
+			if open == -1 {
+				l.report(number, utf8.RuneCountInString(command[:i])+1, RulePlaceholder, "placeholder is closed without being opened")
+			}
+			open = -1
+			// Braces right before the closing ones belong to the placeholder.
+			for i += 2; i < len(command) && command[i] == '}'; i++ {
+			}
+		default:
+			i++
+		}
+	}
+	if open != -1 {
+		l.report(number, utf8.RuneCountInString(command[:open])+1, RulePlaceholder, "placeholder is not closed")
+	}
+}
+
+func sortDiagnostics(diagnostics []Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Line != diagnostics[j].Line {
+			return diagnostics[i].Line < diagnostics[j].Line
+		}
+		return diagnostics[i].Column < diagnostics[j].Column
+	})
+}
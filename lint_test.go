@@ -0,0 +1,97 @@
+package tldr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintValid(t *testing.T) {
+	diagnostics, err := Lint(strings.NewReader(catPage))
+	require.NoError(t, err, "Lint() error %v", err)
+	require.Empty(t, diagnostics)
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     []Diagnostic
+	}{
+		{
+			name:     "empty",
+			markdown: "",
+			want:     []Diagnostic{{Line: 1, Column: 1, Rule: RuleTitle, Message: "page is empty"}},
+		},
+		{
+			name:     "title",
+			markdown: "#cat.\n\n> Print files.\n> More information: <https://example.com>.\n\n- Print a file:\n\n`cat {{file}}`\n",
+			want:     []Diagnostic{{Line: 1, Column: 1, Rule: RuleTitle, Message: "title must start with '# ' followed by the command name"}},
+		},
+		{
+			name:     "description",
+			markdown: "# cat\n\n> print files\n> More information: https://example.com\n\n- Print a file:\n\n`cat {{file}}`\n",
+			want: []Diagnostic{
+				{Line: 3, Column: 3, Rule: RuleDescription, Message: "description must start with an uppercase letter"},
+				{Line: 3, Column: 14, Rule: RuleDescription, Message: "description must end with a period"},
+				{Line: 4, Column: 3, Rule: RuleMoreInfo, Message: "link must be written as 'More information: <url>.'"},
+			},
+		},
+		{
+			name:     "missing more information",
+			markdown: "# cat\n\n> Print files.\n\n- Print a file:\n\n`cat {{file}}`\n",
+			want:     []Diagnostic{{Line: 3, Column: 1, Rule: RuleMoreInfo, Message: "description must end with a \"More information\" link"}},
+		},
+		{
+			name:     "example",
+			markdown: "# cat\n\n> Print files.\n> More information: <https://example.com>.\n\n- print a file\n\ncat {{file}}\n",
+			want: []Diagnostic{
+				{Line: 6, Column: 3, Rule: RuleExampleDescription, Message: "example description must start with an uppercase letter"},
+				{Line: 6, Column: 15, Rule: RuleExampleDescription, Message: "example description must end with a colon"},
+				{Line: 8, Column: 1, Rule: RuleCommand, Message: "command must be wrapped in backticks"},
+			},
+		},
+		{
+			name:     "blank lines",
+			markdown: "# cat\n> Print files.\n> More information: <https://example.com>.\n\n\n- Print a file:\n`cat {{file}}`\n\n",
+			want: []Diagnostic{
+				{Line: 2, Column: 1, Rule: RuleBlankLine, Message: "title must be followed by a blank line"},
+				{Line: 5, Column: 1, Rule: RuleBlankLine, Message: "unexpected blank line"},
+				{Line: 6, Column: 1, Rule: RuleStructure, Message: "example description must be followed by a blank line and the command"},
+				{Line: 7, Column: 1, Rule: RuleStructure, Message: "command must follow an example description and a blank line"},
+				{Line: 8, Column: 1, Rule: RuleBlankLine, Message: "page must not end with a blank line"},
+			},
+		},
+		{
+			name:     "placeholders",
+			markdown: "# cat\n\n> Print files.\n> More information: <https://example.com>.\n\n- Print a file:\n\n`cat {{file {{{{x}}}} }} {{other`\n",
+			want:     []Diagnostic{{Line: 8, Column: 26, Rule: RulePlaceholder, Message: "placeholder is not closed"}},
+		},
+		{
+			name:     "whitespace and line endings",
+			markdown: "# cat \r\n\n> Print files.\n> More information: <https://example.com>.\n\n- Print a file:\t\n\n`cat {{file}}`",
+			want: []Diagnostic{
+				{Line: 1, Column: 6, Rule: RuleTrailingWhitespace, Message: "line has trailing whitespace"},
+				{Line: 1, Column: 7, Rule: RuleLineEnding, Message: "line must end with LF, not CRLF"},
+				{Line: 6, Column: 16, Rule: RuleTrailingWhitespace, Message: "line has trailing whitespace"},
+				{Line: 8, Column: 15, Rule: RuleBlankLine, Message: "file must end with a newline"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			diagnostics, err := Lint(strings.NewReader(tt.markdown))
+			require.NoError(t, err, "Lint() error %v", err)
+			require.Equal(t, tt.want, diagnostics)
+		})
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	diagnostic := Diagnostic{Line: 3, Column: 14, Rule: RuleDescription, Message: "description must end with a period"}
+	require.Equal(t, "3:14: description: description must end with a period", diagnostic.String())
+}
@@ -0,0 +1,92 @@
+package tldr
+
+import (
+	"io"
+	"strings"
+)
+
+var roffEscaper = strings.NewReplacer(`\`, `\e`, "-", `\-`)
+
+// RenderMan renders the page as roff document using the man(7) macros. The
+// description is put in the NAME and DESCRIPTION sections, every example is
+// a tagged paragraph of the EXAMPLES section.
+func RenderMan(page *Page, form OptionForm) string {
+	var rendered strings.Builder
+	rendered.WriteString(".TH \"" + strings.ToUpper(roffEscape(page.Name)) + "\" 1 \"\" \"tldr\" \"tldr pages\"\n")
+
+	rendered.WriteString(".SH NAME\n")
+	rendered.WriteString(roffLine(roffEscape(page.Name)))
+	if len(page.Description) > 0 {
+		rendered.WriteString(" \\- " + roffText(page.Description[0]))
+	}
+	rendered.WriteString("\n")
+
+	if len(page.Description) > 0 || page.MoreInfoURL != "" {
+		rendered.WriteString(".SH DESCRIPTION\n")
+		for _, line := range page.Description {
+			rendered.WriteString(roffLine(roffText(line)) + "\n")
+		}
+		if page.MoreInfoURL != "" {
+			rendered.WriteString(".PP\n" + moreInfoPrefix + "\n.UR " + page.MoreInfoURL + "\n.UE\n")
+		}
+	}
+
+	if len(page.Examples) > 0 {
+		rendered.WriteString(".SH EXAMPLES\n")
+	}
+	for _, example := range page.Examples {
+		rendered.WriteString(".TP\n")
+		var command strings.Builder
+		for _, token := range example.Command {
+			text := roffEscape(token.Display(form))
+			if token.Kind == TokenPlaceholder {
+				command.WriteString(`\fI` + text + `\fB`)
+			} else {
+				command.WriteString(text)
+			}
+		}
+		rendered.WriteString(roffLine(`\fB`+command.String()+`\fR`) + "\n")
+		rendered.WriteString(roffLine(roffText(example.Description)) + "\n")
+	}
+	return rendered.String()
+}
+
+// WriteMan parses the markdown and writes it as man page to the destination.
+func WriteMan(markdown io.Reader, dest io.Writer, form OptionForm) error {
+	page, err := Parse(markdown)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(dest, RenderMan(page, form))
+	return err
+}
+
+func roffEscape(text string) string {
+	return roffEscaper.Replace(text)
+}
+
+// roffText escapes the text of a description and shows `code` in bold.
+func roffText(text string) string {
+	parts := strings.Split(roffEscape(text), "`")
+	var rendered strings.Builder
+	for i, part := range parts {
+		switch {
+		case i%2 == 0:
+			rendered.WriteString(part)
+		case i == len(parts)-1:
+			// Unbalanced backtick
+			rendered.WriteString("`" + part)
+		default:
+			rendered.WriteString(`\fB` + part + `\fR`)
+		}
+	}
+	return rendered.String()
+}
+
+// roffLine prevents the line from being taken as a request.
+func roffLine(line string) string {
+	if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+		return `\&` + line
+	}
+	return line
+}
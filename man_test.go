@@ -0,0 +1,39 @@
+package tldr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMan(t *testing.T) {
+	var out bytes.Buffer
+	err := WriteMan(strings.NewReader(catPage), &out, OptionFormBoth)
+	require.NoError(t, err, "WriteMan() error %v", err)
+
+	require.Equal(t, `.TH "CAT" 1 "" "tldr" "tldr pages"
+.SH NAME
+cat \- Print and concatenate files.
+.SH DESCRIPTION
+Print and concatenate files.
+.PP
+More information:
+.UR https://www.gnu.org/software/coreutils/cat
+.UE
+.SH EXAMPLES
+.TP
+\fBcat \fIpath/to/file\fB\fR
+Print the contents of a file to \fBstdout\fR:
+.TP
+\fBcat \fIpath/to/file1 path/to/file2 ...\fB > \fIpath/to/output_file\fB\fR
+Concatenate several files into an output file:
+`, out.String())
+}
+
+func TestRoffEscaping(t *testing.T) {
+	require.Equal(t, `tar \-\-file C:\e`, roffEscape(`tar --file C:\`))
+	require.Equal(t, `\&.hidden`, roffLine(".hidden"))
+	require.Equal(t, "an \\fBodd\\fR ` count", roffText("an `odd` ` count"))
+}
@@ -0,0 +1,221 @@
+package tldr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	moreInfoPrefix = "More information:"
+	byteOrderMark  = "\ufeff"
+	maxLineLength  = 1024 * 1024
+)
+
+// ParseError reports a malformed page, or a malformed line of it that was
+// skipped.
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Page is the structured representation of a tldr page.
+type Page struct {
+	Name        string
+	Description []string
+	MoreInfoURL string
+	Examples    []Example
+	// Warnings lists the malformed lines the page was parsed despite of.
+	Warnings []*ParseError
+}
+
+// Example is a single example of a page, made of a description and the
+// command it explains.
+type Example struct {
+	Description string
+	Command     []Token
+}
+
+// CommandText returns the command of the example with the placeholder
+// markers removed.
+func (e Example) CommandText() string {
+	var command strings.Builder
+	for _, token := range e.Command {
+		command.WriteString(token.Text)
+	}
+	return command.String()
+}
+
+// Placeholders returns the texts of the placeholders of the command in
+// order.
+func (e Example) Placeholders() []string {
+	var placeholders []string
+	for _, token := range e.Command {
+		if token.Kind == TokenPlaceholder {
+			placeholders = append(placeholders, token.Text)
+		}
+	}
+	return placeholders
+}
+
+// Fill returns the command with the placeholders replaced by the values in
+// order and options displayed in the given form. Placeholders without a
+// value are replaced by their text.
+func (e Example) Fill(values []string, form OptionForm) string {
+	var command strings.Builder
+	i := 0
+	for _, token := range e.Command {
+		if token.Kind != TokenPlaceholder {
+			command.WriteString(token.Display(form))
+			continue
+		}
+		if i < len(values) {
+			command.WriteString(values[i])
+		} else {
+			command.WriteString(token.Text)
+		}
+		i++
+	}
+	return command.String()
+}
+
+// elementKind is the meaning of a line of a page.
+type elementKind int
+
+const (
+	elementName elementKind = iota
+	elementDescription
+	elementMoreInfo
+	elementExample
+	elementCommand
+)
+
+// element is a line of a page. The text of a command is tokenized.
+type element struct {
+	kind   elementKind
+	text   string
+	tokens []Token
+}
+
+// Parse reads the markdown of a page and returns its structured form.
+func Parse(markdown io.Reader) (*Page, error) {
+	page := &Page{}
+	warn := func(warning *ParseError) {
+		page.Warnings = append(page.Warnings, warning)
+	}
+	err := scanPage(markdown, warn, func(e element) error {
+		switch e.kind {
+		case elementName:
+			page.Name = e.text
+		case elementDescription:
+			page.Description = append(page.Description, e.text)
+		case elementMoreInfo:
+			page.MoreInfoURL = e.text
+		case elementExample:
+			page.Examples = append(page.Examples, Example{Description: e.text})
+		case elementCommand:
+			page.Examples[len(page.Examples)-1].Command = e.tokens
+		}
+		return nil
+	})
+	return page, err
+}
+
+// scanPage reads the markdown line by line and calls handle for every
+// element of the page as soon as it is read. Blank lines and surrounding
+// whitespace, including the carriage returns of CRLF line endings, are
+// ignored. Text that isn't part of the page structure is skipped and
+// examples without a command get an empty one, both are passed to warn.
+// Only lines that can't be read are returned as *ParseError.
+func scanPage(markdown io.Reader, warn func(*ParseError), handle func(element) error) error {
+	var number, exampleLine int
+	// missingCommand completes the latest example with an empty command.
+	missingCommand := func() error {
+		warn(&ParseError{Line: exampleLine, Msg: "example has no command"})
+		exampleLine = 0
+		return handle(element{kind: elementCommand, tokens: []Token{}})
+	}
+
+	scanner := bufio.NewScanner(markdown)
+	scanner.Buffer(nil, maxLineLength)
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+		if number == 1 {
+			line = strings.TrimPrefix(line, byteOrderMark)
+		}
+
+		if line == "" {
+			continue
+		}
+		if exampleLine != 0 && (strings.HasPrefix(line, "#") || strings.HasPrefix(line, ">") || strings.HasPrefix(line, "-")) {
+			if err := missingCommand(); err != nil {
+				return err
+			}
+		}
+
+		var e element
+		switch {
+		case strings.HasPrefix(line, "#"):
+			// Heading
+			e = element{kind: elementName, text: strings.TrimSpace(line[1:])}
+		case strings.HasPrefix(line, ">"):
+			// Quote
+			text := strings.TrimSpace(line[1:])
+			if url, ok := parseMoreInfo(text); ok {
+				e = element{kind: elementMoreInfo, text: url}
+			} else {
+				e = element{kind: elementDescription, text: text}
+			}
+		case strings.HasPrefix(line, "-"):
+			// Example
+			e = element{kind: elementExample, text: strings.TrimSpace(line[1:])}
+			exampleLine = number
+		case exampleLine != 0:
+			// Command of the latest example
+			e = element{kind: elementCommand, tokens: tokenize(unwrapCommand(line))}
+			exampleLine = 0
+		default:
+			warn(&ParseError{Line: number, Msg: fmt.Sprintf("unexpected text '%s' outside of an example", line)})
+			continue
+		}
+
+		if err := handle(e); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return &ParseError{Line: number + 1, Msg: err.Error()}
+	}
+	if exampleLine != 0 {
+		return missingCommand()
+	}
+	return nil
+}
+
+// unwrapCommand removes the backticks around a command, backticks being part
+// of the command are kept.
+func unwrapCommand(line string) string {
+	if len(line) >= 2 && strings.HasPrefix(line, "`") && strings.HasSuffix(line, "`") {
+		return line[1 : len(line)-1]
+	}
+	return line
+}
+
+// parseMoreInfo extracts the URL of a "More information: <url>." line.
+func parseMoreInfo(text string) (string, bool) {
+	if !strings.HasPrefix(text, moreInfoPrefix) {
+		return "", false
+	}
+	url := strings.TrimSpace(strings.TrimPrefix(text, moreInfoPrefix))
+	url = strings.TrimSuffix(url, ".")
+	url = strings.TrimPrefix(url, "<")
+	url = strings.TrimSuffix(url, ">")
+	return url, true
+}
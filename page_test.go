@@ -0,0 +1,186 @@
+package tldr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const catPage = `# cat
+
+> Print and concatenate files.
+> More information: <https://www.gnu.org/software/coreutils/cat>.
+
+- Print the contents of a file to ` + "`stdout`" + `:
+
+` + "`cat {{path/to/file}}`" + `
+
+- Concatenate several files into an output file:
+
+` + "`cat {{path/to/file1 path/to/file2 ...}} > {{path/to/output_file}}`" + `
+`
+
+func TestParse(t *testing.T) {
+	page, err := Parse(strings.NewReader(catPage))
+	require.NoError(t, err, "Parse() error %v", err)
+
+	require.Equal(t, "cat", page.Name)
+	require.Equal(t, []string{"Print and concatenate files."}, page.Description)
+	require.Equal(t, "https://www.gnu.org/software/coreutils/cat", page.MoreInfoURL)
+	require.Equal(t, []Example{
+		{
+			Description: "Print the contents of a file to `stdout`:",
+			Command: []Token{
+				{Kind: TokenText, Text: "cat "},
+				{Kind: TokenPlaceholder, Text: "path/to/file"},
+			},
+		},
+		{
+			Description: "Concatenate several files into an output file:",
+			Command: []Token{
+				{Kind: TokenText, Text: "cat "},
+				{Kind: TokenPlaceholder, Text: "path/to/file1 path/to/file2 ..."},
+				{Kind: TokenText, Text: " > "},
+				{Kind: TokenPlaceholder, Text: "path/to/output_file"},
+			},
+		},
+	}, page.Examples)
+}
+
+func TestExampleCommandText(t *testing.T) {
+	page, err := Parse(strings.NewReader(catPage))
+	require.NoError(t, err, "Parse() error %v", err)
+
+	require.Equal(t, "cat path/to/file", page.Examples[0].CommandText())
+}
+
+func TestExampleFill(t *testing.T) {
+	page, err := Parse(strings.NewReader("# tar\n\n- Extract an archive:\n\n`tar {{[-x|--extract]}} -f {{path/to/file}} -C {{path/to/directory}}`\n"))
+	require.NoError(t, err, "Parse() error %v", err)
+	example := page.Examples[0]
+
+	require.Equal(t, []string{"path/to/file", "path/to/directory"}, example.Placeholders())
+	require.Equal(t, "tar -x -f backup.tar -C path/to/directory", example.Fill([]string{"backup.tar"}, OptionFormShort))
+	require.Equal(t, "tar [-x|--extract] -f a.tar -C out", example.Fill([]string{"a.tar", "out"}, OptionFormBoth))
+}
+
+func TestParseTolerated(t *testing.T) {
+	want, err := Parse(strings.NewReader(catPage))
+	require.NoError(t, err, "Parse() error %v", err)
+
+	tests := []struct {
+		name     string
+		markdown string
+	}{
+		{name: "CRLF", markdown: strings.ReplaceAll(catPage, "\n", "\r\n")},
+		{name: "byte order mark", markdown: "\ufeff" + catPage},
+		{name: "no blank lines", markdown: strings.ReplaceAll(catPage, "\n\n", "\n")},
+		{name: "no final newline", markdown: strings.TrimSuffix(catPage, "\n")},
+		{name: "surrounding whitespace", markdown: strings.ReplaceAll(catPage, "\n", "  \n  ")},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			page, err := Parse(strings.NewReader(tt.markdown))
+			require.NoError(t, err, "Parse() error %v", err)
+			require.Equal(t, want, page)
+		})
+	}
+}
+
+func TestParseBareMarkers(t *testing.T) {
+	page, err := Parse(strings.NewReader("#\n>\n-\n`cmd`\n"))
+	require.NoError(t, err, "Parse() error %v", err)
+	require.Equal(t, &Page{
+		Description: []string{""},
+		Examples:    []Example{{Command: []Token{{Kind: TokenText, Text: "cmd"}}}},
+	}, page)
+}
+
+func TestParseWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		examples []Example
+		want     []*ParseError
+	}{
+		{
+			name:     "example at end of file",
+			markdown: "# cat\n\n> Print files.\n\n- Print a file:\n",
+			examples: []Example{{Description: "Print a file:", Command: []Token{}}},
+			want:     []*ParseError{{Line: 5, Msg: "example has no command"}},
+		},
+		{
+			name:     "example followed by example",
+			markdown: "# cat\n\n- Print a file:\n\n- Print another file:\n\n`cat {{file}}`\n",
+			examples: []Example{
+				{Description: "Print a file:", Command: []Token{}},
+				{Description: "Print another file:", Command: []Token{{Kind: TokenText, Text: "cat "}, {Kind: TokenPlaceholder, Text: "file"}}},
+			},
+			want: []*ParseError{{Line: 3, Msg: "example has no command"}},
+		},
+		{
+			name:     "text outside of an example",
+			markdown: "# cat\n\nsome text\n",
+			want:     []*ParseError{{Line: 3, Msg: "unexpected text 'some text' outside of an example"}},
+		},
+		{
+			name:     "second command",
+			markdown: "# cat\n\n- Print a file:\n\n`cat {{file}}`\n\n`cat {{other}}`\n",
+			examples: []Example{{Description: "Print a file:", Command: []Token{{Kind: TokenText, Text: "cat "}, {Kind: TokenPlaceholder, Text: "file"}}}},
+			want:     []*ParseError{{Line: 7, Msg: "unexpected text '`cat {{other}}`' outside of an example"}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			page, err := Parse(strings.NewReader(tt.markdown))
+			require.NoError(t, err, "Parse() error %v", err)
+			require.Equal(t, "cat", page.Name)
+			require.Equal(t, tt.examples, page.Examples)
+			require.Equal(t, tt.want, page.Warnings)
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, err := Parse(strings.NewReader("# cat\n\n> " + strings.Repeat("x", maxLineLength) + "\n"))
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	require.Equal(t, ParseError{Line: 3, Msg: "bufio.Scanner: token too long"}, *parseErr)
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add(catPage)
+	f.Add(strings.ReplaceAll(catPage, "\n", "\r\n"))
+	f.Add("#\n>\n-\n")
+	f.Add("- example\n`{{{{a}}}} {{[-o|--option]}} {{b`\n")
+	f.Fuzz(func(t *testing.T, markdown string) {
+		page, err := Parse(strings.NewReader(markdown))
+		if err != nil {
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			return
+		}
+		for _, example := range page.Examples {
+			require.NotNil(t, example.Command, "example without command in %q", markdown)
+		}
+	})
+}
+
+func FuzzRender(f *testing.F) {
+	f.Add(catPage)
+	f.Add("# x\n> More information: <>.\n- a\n`{{}}`")
+	f.Fuzz(func(t *testing.T, markdown string) {
+		_, err := RenderWithOptions(strings.NewReader(markdown), Options{Color: ColorAlways, Width: 20})
+		if err != nil {
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+		}
+	})
+}
@@ -1,12 +1,19 @@
 package tldr
 
 import (
-	"bufio"
+	"errors"
+	"fmt"
 	"io"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // Output terms
+//
+// Deprecated: the colors are defined by the Theme of the Options.
 const (
 	BLUE  = "\x1b[34;1m"
 	GREEN = "\x1b[32;1m"
@@ -14,47 +21,439 @@ const (
 	RESET = "\x1b[33;0m"
 )
 
-// Render takes the given input and renders it for a prettier output.
-func Render(markdown io.Reader) (string, error) {
-	var rendered string
-	var renderingExample bool
-	scanner := bufio.NewScanner(markdown)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if renderingExample {
-			// Skip the empty line
-			scanner.Scan()
-			line = scanner.Text()
-
-			line = strings.Replace(line, "{{", BLUE, -1)
-			line = strings.Replace(line, "}}", RED, -1)
-			rendered += "\t" + RED + strings.Trim(line, "`") + RESET + "\n"
-
-			renderingExample = false
-		} else if strings.HasPrefix(line, "#") {
-			// Heading
-			rendered += line[2:] + "\n"
-		} else if strings.HasPrefix(line, ">") {
-			// Quote
-			rendered += line[2:] + "\n"
-		} else if strings.HasPrefix(line, "-") {
-			// Example
-			rendered += GREEN + line + RESET + "\n"
-			renderingExample = true
+// ColorMode controls whether the rendered output is colored.
+type ColorMode int
+
+// Color modes
+const (
+	// ColorAuto colors the output if it is written to a terminal, unless
+	// overridden by the NO_COLOR or FORCE_COLOR environment variables.
+	ColorAuto ColorMode = iota
+	// ColorAlways always colors the output.
+	ColorAlways
+	// ColorNever never colors the output.
+	ColorNever
+)
+
+// ParseColorMode returns the color mode for the given name, which is one of
+// auto, always or never.
+func ParseColorMode(name string) (ColorMode, error) {
+	switch strings.ToLower(name) {
+	case "auto":
+		return ColorAuto, nil
+	case "always":
+		return ColorAlways, nil
+	case "never":
+		return ColorNever, nil
+	}
+	return ColorAuto, fmt.Errorf("ERROR: unknown color mode '%s'", name)
+}
+
+// ErrNoMatch is returned when examples are filtered and none of them
+// matches.
+var ErrNoMatch = errors.New("ERROR: no example matches")
+
+// Layout arranges the parts of a rendered page.
+type Layout int
+
+// Layouts
+const (
+	// LayoutDefault separates the title, the description and every example
+	// by blank lines and prints commands below their description.
+	LayoutDefault Layout = iota
+	// LayoutCompact prints the title with the first description line and
+	// every example as "description → command" on a single line if it fits
+	// the width, without blank lines. The remaining description and the
+	// more information link are left out.
+	LayoutCompact
+)
+
+// Options configures the rendering of a page.
+type Options struct {
+	Color ColorMode
+	// Theme styles the output if it is colored, DefaultTheme is used if
+	// it is nil.
+	Theme *Theme
+	// Width is the number of columns descriptions are wrapped at. If it is
+	// zero the width of the terminal written to is used, a negative width
+	// disables wrapping. Commands are never wrapped to keep them copyable.
+	Width int
+	// OptionForm selects how options given in both forms are displayed.
+	OptionForm OptionForm
+	Layout     Layout
+	// Grep filters the examples if it isn't nil: only examples whose
+	// description or command match are shown, with the matches highlighted,
+	// and the description of the page is left out. ErrNoMatch is returned
+	// if no example matches.
+	Grep *regexp.Regexp
+	// Numbered prefixes every example with its number, starting at 1.
+	Numbered bool
+	// Warn is called with the malformed lines of the page, which are skipped
+	// when rendering. They are ignored if it is nil.
+	Warn func(*ParseError)
+}
+
+// Resolve returns the options with the color mode and width decided for
+// output written to dest. They are kept if the page is rendered into a buffer
+// first and copied to dest later on, like through a pager.
+func (o Options) Resolve(dest io.Writer) Options {
+	if useColor(o.Color, dest) {
+		o.Color = ColorAlways
+	} else {
+		o.Color = ColorNever
+	}
+	if o.Width == 0 {
+		if width, _, ok := TerminalSize(dest); ok {
+			o.Width = width
 		} else {
-			rendered += line + "\n"
+			o.Width = -1
 		}
 	}
-	return rendered, scanner.Err()
+	return o
 }
 
-// Write is a convenience function that calls Render and writes the output
-// to the destination.
-func Write(markdown io.Reader, dest io.Writer) error {
-	out, err := Render(markdown)
+func (o Options) theme() Theme {
+	if o.Theme == nil {
+		return DefaultTheme
+	}
+	return *o.Theme
+}
+
+// Renderer renders pages for the terminal according to its options.
+type Renderer struct {
+	opts Options
+}
+
+// NewRenderer returns a renderer using the given options.
+func NewRenderer(opts Options) *Renderer {
+	return &Renderer{opts: opts}
+}
+
+// RenderTo reads the markdown and writes the rendered page to w. Every line
+// is written as soon as it is read, so the output appears while the page is
+// still processed.
+func (r *Renderer) RenderTo(w io.Writer, markdown io.Reader) error {
+	width := r.opts.Width
+	if width == 0 {
+		width, _, _ = TerminalSize(w)
+	}
+	color := useColor(r.opts.Color, w)
+
+	p := &pageWriter{
+		theme:      r.opts.theme().complete(),
+		form:       r.opts.OptionForm,
+		color:      color,
+		hyperlinks: color && IsTerminal(w) && supportsHyperlinks(),
+		width:      width,
+		compact:    r.opts.Layout == LayoutCompact,
+		grep:       r.opts.Grep,
+		numbered:   r.opts.Numbered,
+	}
+	// Malformed lines don't keep the rest of the page from being shown.
+	warn := r.opts.Warn
+	if warn == nil {
+		warn = func(*ParseError) {}
+	}
+	err := scanPage(markdown, warn, func(e element) error {
+		_, err := io.WriteString(w, p.render(e))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, p.flush())
 	if err != nil {
 		return err
 	}
-	_, err = io.WriteString(dest, out)
-	return err
+	if p.grep != nil && !p.matched {
+		return ErrNoMatch
+	}
+	return nil
+}
+
+// Render takes the given input and renders it for a prettier output.
+func Render(markdown io.Reader) (string, error) {
+	return RenderWithOptions(markdown, Options{Color: ColorAlways})
+}
+
+// RenderWithOptions renders the given input according to the options. As the
+// output isn't written to a terminal, ColorAuto only colors it if forced by
+// the environment.
+func RenderWithOptions(markdown io.Reader, opts Options) (string, error) {
+	var rendered strings.Builder
+	err := NewRenderer(opts).RenderTo(&rendered, markdown)
+	if err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
+}
+
+// Write is a convenience function that renders the input and writes the
+// output to the destination.
+func Write(markdown io.Reader, dest io.Writer) error {
+	return WriteWithOptions(markdown, dest, Options{Color: ColorAlways})
+}
+
+// WriteWithOptions renders the given input according to the options and
+// writes the output to the destination.
+func WriteWithOptions(markdown io.Reader, dest io.Writer, opts Options) error {
+	return NewRenderer(opts).RenderTo(dest, markdown)
+}
+
+// useColor decides whether output written to dest should be colored. NO_COLOR
+// takes precedence over FORCE_COLOR, both are ignored unless the mode is
+// ColorAuto.
+func useColor(mode ColorMode, dest io.Writer) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("FORCE_COLOR"); force != "" && force != "0" && force != "false" {
+		return true
+	}
+	return IsTerminal(dest)
+}
+
+// pageWriter renders the elements of a page with the options resolved for
+// the destination.
+type pageWriter struct {
+	theme      Theme
+	form       OptionForm
+	color      bool
+	hyperlinks bool
+	width      int
+	compact    bool
+	grep       *regexp.Regexp
+	matched    bool
+	numbered   bool
+	examples   int
+	sequences  map[Style]string
+
+	// The compact layout holds back the title until the first description
+	// line. The example description is held back until its command if the
+	// layout is compact or the examples are filtered.
+	title       string
+	described   bool
+	description string
+}
+
+func (p *pageWriter) paint(style Style, text string) string {
+	if !p.color || text == "" {
+		return text
+	}
+
+	sequence, ok := p.sequences[style]
+	if !ok {
+		if p.sequences == nil {
+			p.sequences = map[Style]string{}
+		}
+		sequence = style.sequence()
+		p.sequences[style] = sequence
+	}
+	if sequence == "" {
+		return text
+	}
+	return sequence + text + resetSequence
+}
+
+// render returns the output lines of the element.
+func (p *pageWriter) render(e element) string {
+	if p.compact {
+		return p.renderCompact(e)
+	}
+
+	var rendered strings.Builder
+	switch e.kind {
+	case elementName:
+		rendered.WriteString(p.paint(p.theme.Title, e.text) + "\n")
+		if p.grep == nil {
+			rendered.WriteString("\n")
+		}
+	case elementDescription:
+		if p.grep != nil {
+			break
+		}
+		for _, line := range wrap(e.text, p.width, "") {
+			rendered.WriteString(p.paint(p.theme.Description, line) + "\n")
+		}
+	case elementMoreInfo:
+		if p.grep != nil {
+			break
+		}
+		if p.hyperlinks {
+			rendered.WriteString(p.paint(p.theme.Description, moreInfoPrefix+" ") + hyperlink(e.text, p.paint(p.theme.URL, e.text)) + p.paint(p.theme.Description, ".") + "\n")
+		} else {
+			rendered.WriteString(p.paint(p.theme.Description, moreInfoPrefix+" <") + p.paint(p.theme.URL, e.text) + p.paint(p.theme.Description, ">.") + "\n")
+		}
+	case elementExample:
+		p.examples++
+		if p.grep != nil {
+			p.description = e.text
+			break
+		}
+		rendered.WriteString(p.example(e.text))
+	case elementCommand:
+		if p.grep != nil {
+			if !p.match(e.tokens) {
+				break
+			}
+			rendered.WriteString(p.example(p.description))
+		}
+		if len(e.tokens) == 0 {
+			// The example misses its command.
+			break
+		}
+		command, _ := p.command(e.tokens)
+		rendered.WriteString("\t" + command + "\n")
+	}
+	return rendered.String()
+}
+
+func (p *pageWriter) renderCompact(e element) string {
+	var rendered strings.Builder
+	switch e.kind {
+	case elementName:
+		rendered.WriteString(p.flush())
+		p.title = e.text
+		p.described = false
+	case elementDescription:
+		if p.described || p.grep != nil {
+			break
+		}
+		p.described = true
+		lines := wrap(p.title+" - "+e.text, p.width, "  ")
+		if rest, ok := strings.CutPrefix(lines[0], p.title); ok {
+			rendered.WriteString(p.paint(p.theme.Title, p.title) + p.paint(p.theme.Description, rest) + "\n")
+		} else {
+			rendered.WriteString(p.paint(p.theme.Title, lines[0]) + "\n")
+		}
+		for _, line := range lines[1:] {
+			rendered.WriteString(p.paint(p.theme.Description, line) + "\n")
+		}
+		p.title = ""
+	case elementExample:
+		rendered.WriteString(p.flush())
+		p.examples++
+		p.description = e.text
+	case elementCommand:
+		if p.grep != nil && !p.match(e.tokens) {
+			break
+		}
+		command, length := p.command(e.tokens)
+		number := p.number()
+		description := strings.TrimSuffix(p.description, ":")
+		if p.width <= 0 || utf8.RuneCountInString(number+description)+3+length <= p.width {
+			rendered.WriteString(p.paint(p.theme.ExampleDescription, number) + p.highlight(p.theme.ExampleDescription, description) + p.paint(p.theme.ExampleDescription, " → ") + command + "\n")
+		} else {
+			for _, line := range wrap(number+p.description, p.width, strings.Repeat(" ", len(number))) {
+				rendered.WriteString(p.highlight(p.theme.ExampleDescription, line) + "\n")
+			}
+			rendered.WriteString("\t" + command + "\n")
+		}
+		p.description = ""
+	}
+	return rendered.String()
+}
+
+// flush returns the title held back by the compact layout if no description
+// followed it.
+func (p *pageWriter) flush() string {
+	if p.title == "" {
+		return ""
+	}
+	title := p.title
+	p.title = ""
+	p.described = true
+	return p.paint(p.theme.Title, title) + "\n"
+}
+
+// number returns the prefix numbering the latest example, if examples are
+// numbered.
+func (p *pageWriter) number() string {
+	if !p.numbered {
+		return ""
+	}
+	return strconv.Itoa(p.examples) + ". "
+}
+
+// example returns the lines of the example description.
+func (p *pageWriter) example(description string) string {
+	marker := "- "
+	if p.numbered {
+		marker = p.number()
+	}
+
+	var rendered strings.Builder
+	rendered.WriteString("\n")
+	for _, line := range wrap(marker+description, p.width, strings.Repeat(" ", len(marker))) {
+		rendered.WriteString(p.highlight(p.theme.ExampleDescription, line) + "\n")
+	}
+	return rendered.String()
+}
+
+// match reports whether the held back example description or the command
+// match the filter.
+func (p *pageWriter) match(tokens []Token) bool {
+	if !p.grep.MatchString(p.description) && !p.grep.MatchString(p.commandText(tokens)) {
+		return false
+	}
+	p.matched = true
+	return true
+}
+
+// command returns the painted command and its length in characters.
+func (p *pageWriter) command(tokens []Token) (string, int) {
+	var matches [][]int
+	if p.grep != nil {
+		matches = p.grep.FindAllStringIndex(p.commandText(tokens), -1)
+	}
+
+	var rendered strings.Builder
+	offset, length := 0, 0
+	for _, segment := range shellSegments(tokens, p.form) {
+		rendered.WriteString(p.paintMatches(p.theme.shellStyle(segment.kind), segment.text, offset, matches))
+		offset += len(segment.text)
+		length += utf8.RuneCountInString(segment.text)
+	}
+	return rendered.String(), length
+}
+
+func (p *pageWriter) commandText(tokens []Token) string {
+	var text strings.Builder
+	for _, token := range tokens {
+		text.WriteString(token.Display(p.form))
+	}
+	return text.String()
+}
+
+// highlight paints the text, highlighting the matches of the filter.
+func (p *pageWriter) highlight(style Style, text string) string {
+	if p.grep == nil {
+		return p.paint(style, text)
+	}
+	return p.paintMatches(style, text, 0, p.grep.FindAllStringIndex(text, -1))
+}
+
+// paintMatches paints the text, which starts at offset of the matched
+// string, and highlights the parts within the matches.
+func (p *pageWriter) paintMatches(style Style, text string, offset int, matches [][]int) string {
+	var rendered strings.Builder
+	start := 0
+	for _, match := range matches {
+		from, to := match[0]-offset, match[1]-offset
+		if to <= start || from >= len(text) || from == to {
+			continue
+		}
+		from, to = max(from, start), min(to, len(text))
+		rendered.WriteString(p.paint(style, text[start:from]) + p.paint(p.theme.Match, text[from:to]))
+		start = to
+	}
+	rendered.WriteString(p.paint(style, text[start:]))
+	return rendered.String()
 }
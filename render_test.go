@@ -1,11 +1,16 @@
 package tldr
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/mstruebing/tldr/cache"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRender(t *testing.T) {
@@ -34,3 +39,300 @@ func TestWrite(t *testing.T) {
 		t.Error("Expected to write successfully")
 	}
 }
+
+func TestParseColorMode(t *testing.T) {
+	for name, want := range map[string]ColorMode{"auto": ColorAuto, "always": ColorAlways, "NEVER": ColorNever} {
+		mode, err := ParseColorMode(name)
+		require.NoError(t, err, "ParseColorMode(%s) error %v", name, err)
+		require.Equal(t, want, mode)
+	}
+
+	_, err := ParseColorMode("sometimes")
+	require.Error(t, err, "expected an error for an unknown color mode")
+}
+
+func TestWriteWithOptionsColor(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       ColorMode
+		noColor    string
+		forceColor string
+		want       bool
+	}{
+		{name: "always", mode: ColorAlways, want: true},
+		{name: "never", mode: ColorNever, forceColor: "1", want: false},
+		{name: "auto without terminal", mode: ColorAuto, want: false},
+		{name: "auto forced", mode: ColorAuto, forceColor: "1", want: true},
+		{name: "auto forced off", mode: ColorAuto, forceColor: "0", want: false},
+		{name: "auto no color", mode: ColorAuto, noColor: "1", forceColor: "1", want: false},
+		{name: "always ignores no color", mode: ColorAlways, noColor: "1", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			t.Setenv("FORCE_COLOR", tt.forceColor)
+
+			var out bytes.Buffer
+			err := WriteWithOptions(strings.NewReader(catPage), &out, Options{Color: tt.mode})
+			require.NoError(t, err, "WriteWithOptions() error %v", err)
+			require.Equal(t, tt.want, strings.Contains(out.String(), "\x1b["), "unexpected coloring of %q", out.String())
+			require.Contains(t, out.String(), "- Concatenate several files into an output file:")
+		})
+	}
+}
+
+func TestOptionsResolve(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+
+	var out bytes.Buffer
+	require.Equal(t, Options{Color: ColorNever, Width: -1}, Options{Color: ColorAuto}.Resolve(&out))
+	require.Equal(t, Options{Color: ColorAlways, Width: 40}, Options{Color: ColorAlways, Width: 40}.Resolve(&out))
+}
+
+func TestWriteWithOptionsWidth(t *testing.T) {
+	var out bytes.Buffer
+	err := WriteWithOptions(strings.NewReader(catPage), &out, Options{Color: ColorNever, Width: 20})
+	require.NoError(t, err, "WriteWithOptions() error %v", err)
+
+	require.Contains(t, out.String(), "\nPrint and\nconcatenate files.\n")
+	require.Contains(t, out.String(), "\n- Concatenate\n  several files into\n  an output file:\n")
+	require.Contains(t, out.String(), "\n\tcat path/to/file1 path/to/file2 ... > path/to/output_file\n")
+}
+
+func TestWriteWithOptionsOptionForm(t *testing.T) {
+	page := "# tar\n\n> Archiving utility.\n\n- Extract an archive:\n\n`tar {{[-x|--extract]}} {{[-f|--file]}} {{path/to/file}}`\n"
+
+	var out bytes.Buffer
+	err := WriteWithOptions(strings.NewReader(page), &out, Options{Color: ColorNever, OptionForm: OptionFormLong})
+	require.NoError(t, err, "WriteWithOptions() error %v", err)
+	require.Contains(t, out.String(), "\ttar --extract --file path/to/file\n")
+}
+
+type chanWriter chan string
+
+func (c chanWriter) Write(p []byte) (int, error) {
+	c <- string(p)
+	return len(p), nil
+}
+
+func TestRenderToStreams(t *testing.T) {
+	markdown, input := io.Pipe()
+	output := make(chanWriter, 10)
+	done := make(chan error)
+	go func() {
+		done <- NewRenderer(Options{Color: ColorNever}).RenderTo(output, markdown)
+	}()
+
+	// The heading is written before the rest of the page is available.
+	_, err := io.WriteString(input, "# cat\n")
+	require.NoError(t, err)
+	require.Equal(t, "cat\n\n", <-output)
+
+	_, err = io.WriteString(input, "\n- Print a file:\n\n`cat {{file}}`\n")
+	require.NoError(t, err)
+	require.NoError(t, input.Close())
+	require.Equal(t, "\n- Print a file:\n", <-output)
+	require.Equal(t, "\tcat file\n", <-output)
+	require.NoError(t, <-done)
+}
+
+func TestRenderWithOptionsMatchesRenderTo(t *testing.T) {
+	opts := Options{Color: ColorAlways, Width: 30}
+	rendered, err := RenderWithOptions(strings.NewReader(catPage), opts)
+	require.NoError(t, err, "RenderWithOptions() error %v", err)
+
+	var out bytes.Buffer
+	err = NewRenderer(opts).RenderTo(&out, strings.NewReader(catPage))
+	require.NoError(t, err, "RenderTo() error %v", err)
+	require.Equal(t, out.String(), rendered)
+}
+
+// largePage returns a page with the given number of examples.
+func largePage(examples int) string {
+	var page strings.Builder
+	page.WriteString("# large\n\n> A very large page.\n> More information: <https://example.com>.\n")
+	for i := 0; i < examples; i++ {
+		fmt.Fprintf(&page, "\n- Example number %d with a longer description to wrap:\n\n`large --example {{%d}} {{[-o|--option]}} {{path/to/file}}`\n", i, i)
+	}
+	return page.String()
+}
+
+func BenchmarkRenderTo(b *testing.B) {
+	page := largePage(10000)
+	renderer := NewRenderer(Options{Color: ColorAlways, Width: 80})
+	b.SetBytes(int64(len(page)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := renderer.RenderTo(io.Discard, strings.NewReader(page)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderWithOptions(b *testing.B) {
+	page := largePage(10000)
+	opts := Options{Color: ColorAlways, Width: 80}
+	b.SetBytes(int64(len(page)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := RenderWithOptions(strings.NewReader(page), opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRenderWithOptionsMalformed(t *testing.T) {
+	var warnings []ParseError
+	rendered, err := RenderWithOptions(strings.NewReader("# cat\n\nsome text\n\n- Print a file:\n\n- Print another file:\n\n`cat {{file}}`\n"), Options{Color: ColorNever, Width: -1, Warn: func(warning *ParseError) { warnings = append(warnings, *warning) }})
+	require.NoError(t, err, "RenderWithOptions() error %v", err)
+	require.Equal(t, "cat\n\n\n- Print a file:\n\n- Print another file:\n\tcat file\n", rendered)
+	require.Equal(t, []ParseError{
+		{Line: 3, Msg: "unexpected text 'some text' outside of an example"},
+		{Line: 5, Msg: "example has no command"},
+	}, warnings)
+}
+
+func TestWriteWithOptionsCompact(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		width    int
+		want     string
+	}{
+		{
+			name:     "unlimited width",
+			markdown: catPage,
+			width:    -1,
+			want:     "cat - Print and concatenate files.\nPrint the contents of a file to `stdout` → cat path/to/file\nConcatenate several files into an output file → cat path/to/file1 path/to/file2 ... > path/to/output_file\n",
+		},
+		{
+			name:     "too narrow",
+			markdown: catPage,
+			width:    60,
+			want:     "cat - Print and concatenate files.\nPrint the contents of a file to `stdout` → cat path/to/file\nConcatenate several files into an output file:\n\tcat path/to/file1 path/to/file2 ... > path/to/output_file\n",
+		},
+		{
+			name:     "first description line only",
+			markdown: "# git\n\n> Version control.\n> Some subcommands have their own pages.\n\n- Show the version:\n\n`git --version`\n",
+			width:    -1,
+			want:     "git - Version control.\nShow the version → git --version\n",
+		},
+		{
+			name:     "no description",
+			markdown: "# true\n\n- Succeed:\n\n`true`\n",
+			width:    -1,
+			want:     "true\nSucceed → true\n",
+		},
+		{
+			name:     "title only",
+			markdown: "# true\n",
+			width:    -1,
+			want:     "true\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			err := WriteWithOptions(strings.NewReader(tt.markdown), &out, Options{Color: ColorNever, Width: tt.width, Layout: LayoutCompact})
+			require.NoError(t, err, "WriteWithOptions() error %v", err)
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestWriteWithOptionsGrep(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		layout  Layout
+		want    string
+		err     error
+	}{
+		{
+			name:    "description",
+			pattern: "several",
+			want:    "cat\n\n- Concatenate several files into an output file:\n\tcat path/to/file1 path/to/file2 ... > path/to/output_file\n",
+		},
+		{
+			name:    "command",
+			pattern: `output_\w+`,
+			want:    "cat\n\n- Concatenate several files into an output file:\n\tcat path/to/file1 path/to/file2 ... > path/to/output_file\n",
+		},
+		{
+			name:    "several examples",
+			pattern: "file",
+			layout:  LayoutCompact,
+			want:    "cat\nPrint the contents of a file to `stdout` → cat path/to/file\nConcatenate several files into an output file → cat path/to/file1 path/to/file2 ... > path/to/output_file\n",
+		},
+		{
+			name:    "no match",
+			pattern: "remove",
+			want:    "cat\n",
+			err:     ErrNoMatch,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			opts := Options{Color: ColorNever, Width: -1, Layout: tt.layout, Grep: regexp.MustCompile(tt.pattern)}
+			err := WriteWithOptions(strings.NewReader(catPage), &out, opts)
+			require.ErrorIs(t, err, tt.err)
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestWriteWithOptionsGrepHighlight(t *testing.T) {
+	theme := Theme{Command: Style{Bold: true}, Placeholder: Style{Underline: true}, Match: Style{Foreground: "yellow"}}
+	opts := Options{Color: ColorAlways, Theme: &theme, Width: -1, Grep: regexp.MustCompile("t p")}
+
+	var out bytes.Buffer
+	err := WriteWithOptions(strings.NewReader("# cat\n\n- Print a file:\n\n`cat {{path}}`\n"), &out, opts)
+	require.NoError(t, err, "WriteWithOptions() error %v", err)
+	// The match spans the command and the placeholder.
+	require.Equal(t, "cat\n\n- Print a file:\n\t\x1b[1mca\x1b[0m\x1b[33mt\x1b[0m\x1b[33m \x1b[0m\x1b[33mp\x1b[0m\x1b[4math\x1b[0m\n", out.String())
+}
+
+func TestWriteWithOptionsNumbered(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "default layout",
+			opts: Options{Width: 30},
+			want: "\n1. Print the contents of a\n   file to `stdout`:\n\tcat path/to/file\n\n2. Concatenate several files\n   into an output file:\n",
+		},
+		{
+			name: "compact layout",
+			opts: Options{Width: -1, Layout: LayoutCompact},
+			want: "\n1. Print the contents of a file to `stdout` → cat path/to/file\n2. Concatenate",
+		},
+		{
+			name: "filtered",
+			opts: Options{Width: -1, Grep: regexp.MustCompile("several")},
+			want: "cat\n\n2. Concatenate several files into an output file:\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.opts.Color = ColorNever
+			tt.opts.Numbered = true
+			var out bytes.Buffer
+			err := WriteWithOptions(strings.NewReader(catPage), &out, tt.opts)
+			require.NoError(t, err, "WriteWithOptions() error %v", err)
+			require.Contains(t, out.String(), tt.want)
+		})
+	}
+}
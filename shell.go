@@ -0,0 +1,276 @@
+package tldr
+
+import "strings"
+
+// shellKind is the meaning of a part of a command taken as shell code.
+type shellKind int
+
+const (
+	shellText shellKind = iota
+	shellProgram
+	shellFlag
+	shellString
+	shellOperator
+	shellVariable
+	shellPlaceholder
+)
+
+// shellSegment is a part of a command of the same kind.
+type shellSegment struct {
+	kind shellKind
+	text string
+}
+
+// Wrappers run the program given as their argument.
+var shellWrappers = map[string]bool{"sudo": true, "doas": true, "time": true, "nohup": true, "exec": true, "command": true, "env": true}
+
+// shellSegments splits the displayed command into segments by their meaning
+// in shell code. Placeholders keep their own kind and options are flags.
+// Code that isn't valid shell, like an unterminated quote, is left as text,
+// so commands in other languages are still shown sensibly.
+func shellSegments(tokens []Token, form OptionForm) []shellSegment {
+	// Placeholders and options are masked as plain words, so their text
+	// isn't taken as shell code.
+	var masked strings.Builder
+	var texts []string
+	for _, token := range tokens {
+		text := token.Display(form)
+		texts = append(texts, text)
+		switch token.Kind {
+		case TokenPlaceholder:
+			masked.WriteString(strings.Repeat("x", len(text)))
+		case TokenOption:
+			if text != "" {
+				masked.WriteString("-" + strings.Repeat("x", len(text)-1))
+			}
+		default:
+			masked.WriteString(text)
+		}
+	}
+	kinds := lexShell(masked.String())
+
+	var segments []shellSegment
+	offset := 0
+	for i, token := range tokens {
+		text := texts[i]
+		if token.Kind == TokenPlaceholder {
+			segments = append(segments, shellSegment{kind: shellPlaceholder, text: text})
+			offset += len(text)
+			continue
+		}
+		start := 0
+		for j := 1; j <= len(text); j++ {
+			if j == len(text) || kinds[offset+j] != kinds[offset+start] {
+				segments = append(segments, shellSegment{kind: kinds[offset+start], text: text[start:j]})
+				start = j
+			}
+		}
+		offset += len(text)
+	}
+	return segments
+}
+
+// lexShell returns the kind of every byte of the shell code.
+func lexShell(code string) []shellKind {
+	kinds := make([]shellKind, len(code))
+	mark := func(from, to int, kind shellKind) {
+		for i := from; i < to; i++ {
+			kinds[i] = kind
+		}
+	}
+
+	expectProgram, wrapped := true, false
+	for i := 0; i < len(code); {
+		c := code[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.IndexByte("|&;()<>", c) != -1 || isRedirection(code[i:]):
+			end := operatorEnd(code, i)
+			operator := code[i:end]
+			mark(i, end, shellOperator)
+			if strings.ContainsAny(operator, "|;(") || (strings.Contains(operator, "&") && !strings.ContainsAny(operator, "<>")) {
+				expectProgram, wrapped = true, false
+			}
+			i = end
+		default:
+			end, word := lexWord(code, i, kinds)
+			switch {
+			case expectProgram && isAssignment(word):
+				mark(i, i+strings.IndexByte(word, '='), shellVariable)
+			case expectProgram && wrapped && strings.HasPrefix(word, "-"):
+				// The options of the wrapper may take arguments, so the
+				// program can't be told apart anymore.
+				markText(kinds, i, end, shellFlag)
+				expectProgram, wrapped = false, false
+			case expectProgram:
+				markText(kinds, i, end, shellProgram)
+				expectProgram = shellWrappers[word]
+				wrapped = expectProgram
+			case strings.HasPrefix(word, "-"):
+				markText(kinds, i, end, shellFlag)
+			}
+			i = end
+		}
+	}
+	return kinds
+}
+
+// lexWord finds the end of the word starting at start and marks the strings
+// and variables in it. It returns the end and the text of the word.
+func lexWord(code string, start int, kinds []shellKind) (int, string) {
+	i := start
+	for i < len(code) {
+		c := code[i]
+		switch {
+		case c == ' ' || c == '\t' || strings.IndexByte("|&;()<>", c) != -1:
+			return i, code[start:i]
+		case c == '\\':
+			i = min(i+2, len(code))
+		case c == '\'' || c == '"':
+			end := quoteEnd(code, i)
+			if end == -1 {
+				// Unterminated, like an apostrophe in another language
+				i++
+				continue
+			}
+			for j := i; j < end; j++ {
+				kinds[j] = shellString
+			}
+			if c == '"' {
+				markVariables(code, i, end, kinds)
+			}
+			i = end
+		case c == '$':
+			end := variableEnd(code, i)
+			for j := i; j < end; j++ {
+				kinds[j] = shellVariable
+			}
+			i = max(end, i+1)
+		default:
+			i++
+		}
+	}
+	return i, code[start:i]
+}
+
+// markText sets the kind of the bytes of a word not being part of a string
+// or variable.
+func markText(kinds []shellKind, from, to int, kind shellKind) {
+	for i := from; i < to; i++ {
+		if kinds[i] == shellText {
+			kinds[i] = kind
+		}
+	}
+}
+
+// quoteEnd returns the index after the quote closing the one at start, or
+// -1 if it isn't closed.
+func quoteEnd(code string, start int) int {
+	quote := code[start]
+	for i := start + 1; i < len(code); i++ {
+		switch {
+		case code[i] == '\\' && quote == '"':
+			i++
+		case code[i] == quote:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// markVariables marks the variables within a double quoted string.
+func markVariables(code string, from, to int, kinds []shellKind) {
+	for i := from; i < to; i++ {
+		switch code[i] {
+		case '\\':
+			i++
+		case '$':
+			end := min(variableEnd(code, i), to-1)
+			for j := i; j < end; j++ {
+				kinds[j] = shellVariable
+			}
+			i = max(end-1, i)
+		}
+	}
+}
+
+// variableEnd returns the index after the variable at start: $NAME, ${...}
+// or a special parameter like $1 or $?. It returns start if the $ doesn't
+// start a variable.
+func variableEnd(code string, start int) int {
+	i := start + 1
+	if i >= len(code) {
+		return start
+	}
+	switch c := code[i]; {
+	case c == '{':
+		if end := strings.IndexByte(code[i:], '}'); end != -1 {
+			return i + end + 1
+		}
+		return start
+	case strings.IndexByte("?!#$@*-0123456789", c) != -1:
+		return i + 1
+	}
+	for i < len(code) && isNameByte(code[i]) {
+		i++
+	}
+	if i == start+1 {
+		return start
+	}
+	return i
+}
+
+// operatorEnd returns the index after the operator at start. Redirections
+// include their file descriptors, like 2>&1.
+func operatorEnd(code string, start int) int {
+	i := start
+	for isDigit(code[i]) {
+		i++
+	}
+	if code[i] == '(' || code[i] == ')' {
+		return i + 1
+	}
+	for i < len(code) && strings.IndexByte("|&;<>", code[i]) != -1 {
+		i++
+	}
+	// Duplicated file descriptors, like 2>&1 or >&-
+	if operator := code[start:i]; strings.HasSuffix(operator, ">&") || strings.HasSuffix(operator, "<&") {
+		for i < len(code) && (isDigit(code[i]) || code[i] == '-') {
+			i++
+		}
+	}
+	return i
+}
+
+// isRedirection reports whether the code starts with a file descriptor
+// being redirected, like 2> or 2>&1.
+func isRedirection(code string) bool {
+	i := 0
+	for i < len(code) && isDigit(code[i]) {
+		i++
+	}
+	return i > 0 && i < len(code) && (code[i] == '>' || code[i] == '<')
+}
+
+// isAssignment reports whether the word assigns a variable, like LANG=C.
+func isAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	if !ok || name == "" || isDigit(name[0]) {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isNameByte(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,81 @@
+package tldr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellSegments(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []shellSegment
+	}{
+		{
+			name:    "program and flags",
+			command: "tar -xf {{archive.tar}} --verbose",
+			want: []shellSegment{
+				{shellProgram, "tar"}, {shellText, " "}, {shellFlag, "-xf"}, {shellText, " "},
+				{shellPlaceholder, "archive.tar"}, {shellText, " "}, {shellFlag, "--verbose"},
+			},
+		},
+		{
+			name:    "options",
+			command: "ls {{[-a|--all]}}",
+			want:    []shellSegment{{shellProgram, "ls"}, {shellText, " "}, {shellFlag, "[-a|--all]"}},
+		},
+		{
+			name:    "pipes and redirections",
+			command: "cat {{file}} | grep foo 2>&1 > out",
+			want: []shellSegment{
+				{shellProgram, "cat"}, {shellText, " "}, {shellPlaceholder, "file"}, {shellText, " "},
+				{shellOperator, "|"}, {shellText, " "}, {shellProgram, "grep"}, {shellText, " foo "},
+				{shellOperator, "2>&1"}, {shellText, " "}, {shellOperator, ">"}, {shellText, " out"},
+			},
+		},
+		{
+			name:    "strings and variables",
+			command: `LANG=C echo "$HOME/{{dir}}" 'a $b' $1`,
+			want: []shellSegment{
+				{shellVariable, "LANG"}, {shellText, "=C "}, {shellProgram, "echo"}, {shellText, " "},
+				{shellString, `"`}, {shellVariable, "$HOME"}, {shellString, "/"}, {shellPlaceholder, "dir"}, {shellString, `"`},
+				{shellText, " "}, {shellString, "'a $b'"}, {shellText, " "}, {shellVariable, "$1"},
+			},
+		},
+		{
+			name:    "wrapper",
+			command: "sudo systemctl restart {{unit}} && echo $",
+			want: []shellSegment{
+				{shellProgram, "sudo"}, {shellText, " "}, {shellProgram, "systemctl"}, {shellText, " restart "},
+				{shellPlaceholder, "unit"}, {shellText, " "}, {shellOperator, "&&"}, {shellText, " "},
+				{shellProgram, "echo"}, {shellText, " $"},
+			},
+		},
+		{
+			name:    "unterminated quote",
+			command: "Get-Content {{path}} | Where-Object { $_ -match 'it''s }",
+			want: []shellSegment{
+				{shellProgram, "Get-Content"}, {shellText, " "}, {shellPlaceholder, "path"}, {shellText, " "},
+				{shellOperator, "|"}, {shellText, " "}, {shellProgram, "Where-Object"}, {shellText, " { "},
+				{shellVariable, "$_"}, {shellText, " "}, {shellFlag, "-match"}, {shellText, " "},
+				{shellString, "'it'"}, {shellText, "'s }"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, shellSegments(tokenize(tt.command), OptionFormBoth))
+		})
+	}
+}
+
+func TestThemeComplete(t *testing.T) {
+	theme := Theme{Command: Style{Bold: true}, Flag: Style{Underline: true}}.complete()
+	require.Equal(t, Style{Bold: true}, theme.Program)
+	require.Equal(t, Style{Underline: true}, theme.Flag)
+	require.Equal(t, DefaultTheme.Match, theme.Match)
+}
@@ -0,0 +1,39 @@
+package tldr
+
+import (
+	"io"
+	"os"
+	"strconv"
+)
+
+// IsTerminal reports whether the writer is a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// TerminalSize returns the number of columns and rows of the terminal the
+// writer is connected to. Where the size can't be queried the COLUMNS and
+// LINES environment variables are used instead.
+func TerminalSize(w io.Writer) (width, height int, ok bool) {
+	if !IsTerminal(w) {
+		return 0, 0, false
+	}
+	if width, height, ok = terminalSize(w.(*os.File).Fd()); ok {
+		return width, height, true
+	}
+
+	width, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err != nil || width <= 0 {
+		return 0, 0, false
+	}
+	height, _ = strconv.Atoi(os.Getenv("LINES"))
+	return width, height, true
+}
@@ -0,0 +1,24 @@
+//go:build linux || darwin || freebsd || netbsd || dragonfly
+
+package tldr
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+type winsize struct {
+	rows    uint16
+	columns uint16
+	xpixel  uint16
+	ypixel  uint16
+}
+
+func terminalSize(fd uintptr) (width, height int, ok bool) {
+	var ws winsize
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 || ws.columns == 0 {
+		return 0, 0, false
+	}
+	return int(ws.columns), int(ws.rows), true
+}
@@ -0,0 +1,7 @@
+//go:build !(linux || darwin || freebsd || netbsd || dragonfly)
+
+package tldr
+
+func terminalSize(fd uintptr) (width, height int, ok bool) {
+	return 0, 0, false
+}
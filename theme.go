@@ -0,0 +1,208 @@
+package tldr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const resetSequence = "\x1b[0m"
+
+var colorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// Color is a terminal color. It is either one of the names black, red,
+// green, yellow, blue, magenta, cyan and white, optionally prefixed with
+// "bright-", a number of the 256 color palette or a "#rrggbb" truecolor
+// value. The empty color is the terminal's default.
+type Color string
+
+// UnmarshalText validates the color while decoding it.
+func (c *Color) UnmarshalText(text []byte) error {
+	color := Color(strings.ToLower(string(text)))
+	if _, err := color.code(false); err != nil {
+		return err
+	}
+	*c = color
+	return nil
+}
+
+// code returns the SGR parameters selecting the color as foreground or
+// background.
+func (c Color) code(background bool) (string, error) {
+	name := string(c)
+	if name == "" {
+		return "", nil
+	}
+
+	base := 30
+	if background {
+		base = 40
+	}
+
+	if strings.HasPrefix(name, "#") {
+		rgb, err := strconv.ParseUint(name[1:], 16, 32)
+		if err != nil || len(name) != 7 {
+			return "", fmt.Errorf("ERROR: invalid truecolor '%s'", name)
+		}
+		return fmt.Sprintf("%d;2;%d;%d;%d", base+8, rgb>>16, (rgb>>8)&0xff, rgb&0xff), nil
+	}
+
+	if index, err := strconv.ParseUint(name, 10, 8); err == nil {
+		return fmt.Sprintf("%d;5;%d", base+8, index), nil
+	}
+
+	if strings.HasPrefix(name, "bright-") {
+		base += 60
+		name = strings.TrimPrefix(name, "bright-")
+	}
+	for i, colorName := range colorNames {
+		if colorName == name {
+			return strconv.Itoa(base + i), nil
+		}
+	}
+	return "", fmt.Errorf("ERROR: unknown color '%s'", c)
+}
+
+// Style describes how a part of a page is displayed.
+type Style struct {
+	Foreground Color `json:"foreground,omitempty"`
+	Background Color `json:"background,omitempty"`
+	Bold       bool  `json:"bold,omitempty"`
+	Underline  bool  `json:"underline,omitempty"`
+}
+
+// sequence returns the escape sequence switching to the style, it is empty
+// for the zero style.
+func (s Style) sequence() string {
+	var codes []string
+	if s.Bold {
+		codes = append(codes, "1")
+	}
+	if s.Underline {
+		codes = append(codes, "4")
+	}
+	if code, err := s.Foreground.code(false); err == nil && code != "" {
+		codes = append(codes, code)
+	}
+	if code, err := s.Background.code(true); err == nil && code != "" {
+		codes = append(codes, code)
+	}
+
+	if len(codes) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+// Theme holds the styles of the different parts of a rendered page.
+type Theme struct {
+	Title              Style `json:"title"`
+	Description        Style `json:"description"`
+	ExampleDescription Style `json:"example_description"`
+	Command            Style `json:"command"`
+	Placeholder        Style `json:"placeholder"`
+	URL                Style `json:"url"`
+	// Match highlights the matches of a filter.
+	Match Style `json:"match"`
+
+	// The parts of a command taken as shell code. Parts without a style
+	// use the Command style.
+	Program  Style `json:"program"`
+	Flag     Style `json:"flag"`
+	String   Style `json:"string"`
+	Operator Style `json:"operator"`
+	Variable Style `json:"variable"`
+}
+
+// complete fills in the styles left out by the theme.
+func (t Theme) complete() Theme {
+	if t.Match == (Style{}) {
+		t.Match = DefaultTheme.Match
+	}
+	for _, style := range []*Style{&t.Program, &t.Flag, &t.String, &t.Operator, &t.Variable} {
+		if *style == (Style{}) {
+			*style = t.Command
+		}
+	}
+	return t
+}
+
+// shellStyle returns the style of a part of a command.
+func (t Theme) shellStyle(kind shellKind) Style {
+	switch kind {
+	case shellProgram:
+		return t.Program
+	case shellFlag:
+		return t.Flag
+	case shellString:
+		return t.String
+	case shellOperator:
+		return t.Operator
+	case shellVariable:
+		return t.Variable
+	case shellPlaceholder:
+		return t.Placeholder
+	}
+	return t.Command
+}
+
+// DefaultTheme is the theme used if no other is selected.
+var DefaultTheme = Theme{
+	ExampleDescription: Style{Foreground: "green", Bold: true},
+	Command:            Style{Foreground: "red", Bold: true},
+	Placeholder:        Style{Foreground: "blue", Bold: true},
+	Match:              Style{Foreground: "black", Background: "yellow"},
+	Program:            Style{Foreground: "red", Bold: true},
+	Flag:               Style{Foreground: "magenta"},
+	String:             Style{Foreground: "yellow"},
+	Operator:           Style{Foreground: "cyan", Bold: true},
+	Variable:           Style{Foreground: "cyan"},
+}
+
+var builtinThemes = map[string]Theme{
+	"default": DefaultTheme,
+	"light": {
+		Title:              Style{Bold: true},
+		ExampleDescription: Style{Foreground: "22"},
+		Command:            Style{Foreground: "88"},
+		Placeholder:        Style{Foreground: "19", Underline: true},
+		URL:                Style{Foreground: "19", Underline: true},
+		Match:              Style{Background: "229"},
+		Program:            Style{Foreground: "88", Bold: true},
+		Flag:               Style{Foreground: "90"},
+		String:             Style{Foreground: "130"},
+		Operator:           Style{Foreground: "30", Bold: true},
+		Variable:           Style{Foreground: "31"},
+	},
+	"dark": {
+		Title:              Style{Foreground: "bright-white", Bold: true},
+		Description:        Style{Foreground: "250"},
+		ExampleDescription: Style{Foreground: "114"},
+		Command:            Style{Foreground: "203"},
+		Placeholder:        Style{Foreground: "75", Underline: true},
+		URL:                Style{Foreground: "75", Underline: true},
+		Match:              Style{Foreground: "black", Background: "220"},
+		Program:            Style{Foreground: "203", Bold: true},
+		Flag:               Style{Foreground: "176"},
+		String:             Style{Foreground: "180"},
+		Operator:           Style{Foreground: "80", Bold: true},
+		Variable:           Style{Foreground: "117"},
+	},
+	"monochrome": {
+		Title:       Style{Bold: true},
+		Command:     Style{Bold: true},
+		Placeholder: Style{Underline: true},
+		URL:         Style{Underline: true},
+		Match:       Style{Bold: true, Underline: true},
+	},
+}
+
+// BuiltinTheme returns the built-in theme with the given name, which is one
+// of default, light, dark or monochrome.
+func BuiltinTheme(name string) (Theme, error) {
+	theme, ok := builtinThemes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("ERROR: unknown theme '%s'", name)
+	}
+	return theme, nil
+}
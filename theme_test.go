@@ -0,0 +1,62 @@
+package tldr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStyleSequence(t *testing.T) {
+	tests := []struct {
+		name  string
+		style Style
+		want  string
+	}{
+		{name: "empty", style: Style{}, want: ""},
+		{name: "basic", style: Style{Foreground: "red", Bold: true}, want: "\x1b[1;31m"},
+		{name: "bright", style: Style{Foreground: "bright-blue", Background: "white"}, want: "\x1b[94;47m"},
+		{name: "256 colors", style: Style{Foreground: "214", Underline: true}, want: "\x1b[4;38;5;214m"},
+		{name: "truecolor", style: Style{Background: "#ff8000"}, want: "\x1b[48;2;255;128;0m"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, tt.style.sequence())
+		})
+	}
+}
+
+func TestColorUnmarshalText(t *testing.T) {
+	for _, valid := range []string{"red", "Bright-Magenta", "0", "255", "#00ff00"} {
+		var color Color
+		require.NoError(t, color.UnmarshalText([]byte(valid)), "expected %s to be a valid color", valid)
+	}
+
+	for _, invalid := range []string{"purple", "256", "#00ff0", "#gggggg"} {
+		var color Color
+		require.Error(t, color.UnmarshalText([]byte(invalid)), "expected %s to be an invalid color", invalid)
+	}
+}
+
+func TestBuiltinTheme(t *testing.T) {
+	for _, name := range []string{"default", "light", "dark", "monochrome"} {
+		_, err := BuiltinTheme(name)
+		require.NoError(t, err, "BuiltinTheme(%s) error %v", name, err)
+	}
+
+	_, err := BuiltinTheme("unknown")
+	require.Error(t, err, "expected an error for an unknown theme")
+}
+
+func TestWriteWithOptionsTheme(t *testing.T) {
+	theme := Theme{Placeholder: Style{Foreground: "#010203"}}
+
+	var out bytes.Buffer
+	err := WriteWithOptions(strings.NewReader(catPage), &out, Options{Color: ColorAlways, Theme: &theme})
+	require.NoError(t, err, "WriteWithOptions() error %v", err)
+	require.Contains(t, out.String(), "\tcat \x1b[38;2;1;2;3mpath/to/file\x1b[0m\n")
+}
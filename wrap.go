@@ -0,0 +1,32 @@
+package tldr
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// wrap breaks the text into lines of at most width characters at spaces.
+// Following lines are prefixed by the indent, words longer than a line are
+// kept intact.
+func wrap(text string, width int, indent string) []string {
+	words := strings.Fields(text)
+	if width <= 0 || len(words) == 0 {
+		return []string{text}
+	}
+
+	var lines []string
+	line := words[0]
+	length := utf8.RuneCountInString(line)
+	for _, word := range words[1:] {
+		wordLength := utf8.RuneCountInString(word)
+		if length+1+wordLength > width {
+			lines = append(lines, line)
+			line = indent + word
+			length = utf8.RuneCountInString(indent) + wordLength
+			continue
+		}
+		line += " " + word
+		length += 1 + wordLength
+	}
+	return append(lines, line)
+}
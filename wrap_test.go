@@ -0,0 +1,63 @@
+package tldr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		width  int
+		indent string
+		want   []string
+	}{
+		{
+			name:  "no width",
+			text:  "a text that is not wrapped",
+			width: 0,
+			want:  []string{"a text that is not wrapped"},
+		},
+		{
+			name:  "fits",
+			text:  "short text",
+			width: 10,
+			want:  []string{"short text"},
+		},
+		{
+			name:  "wrapped",
+			text:  "a text that is wrapped",
+			width: 10,
+			want:  []string{"a text", "that is", "wrapped"},
+		},
+		{
+			name:   "hanging indent",
+			text:   "- Print the contents of a file:",
+			width:  16,
+			indent: "  ",
+			want:   []string{"- Print the", "  contents of a", "  file:"},
+		},
+		{
+			name:  "long word",
+			text:  "see https://example.com/a/very/long/path",
+			width: 10,
+			want:  []string{"see", "https://example.com/a/very/long/path"},
+		},
+		{
+			name:  "multibyte",
+			text:  "äöü äöü äöü",
+			width: 7,
+			want:  []string{"äöü äöü", "äöü"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.want, wrap(tt.text, tt.width, tt.indent))
+		})
+	}
+}